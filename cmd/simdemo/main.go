@@ -0,0 +1,195 @@
+// simdemo runs the same happy-path / fraud-path scenarios as cmd/demo, but
+// against pkg/adapters/simchain instead of mockchain: a real Solidity Vault
+// contract on an in-process go-ethereum dev node, with on-chain ecrecover of
+// quorum signatures. It exists to exercise the real EVM path mockchain can't:
+// an invalid or stale-state withdrawal request is rejected by the contract
+// itself, not just by the mock's Go logic.
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/mod/clearnet/pkg/adapters/mockp2p"
+	"github.com/mod/clearnet/pkg/adapters/mockregistry"
+	"github.com/mod/clearnet/pkg/adapters/simchain"
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/node"
+)
+
+const (
+	NumNodes = 10
+	Quorum   = 3
+)
+
+func main() {
+	fmt.Println("=== Clearnet Simulation (real EVM via simchain) ===")
+
+	// 1. Setup Infrastructure
+	chain, err := simchain.NewChain(2 * time.Second) // 2s challenge period for demo
+	if err != nil {
+		fmt.Printf("simchain.NewChain: %v\n", err)
+		return
+	}
+	defer chain.Close()
+	network := mockp2p.NewMockP2P()
+	registry := mockregistry.New()
+
+	// 2. Bootstrap Nodes
+	nodes := make([]*node.Node, NumNodes)
+	for i := 0; i < NumNodes; i++ {
+		id := fmt.Sprintf("node_%d", i)
+		if _, err := chain.AddNode(id); err != nil {
+			fmt.Printf("chain.AddNode(%s): %v\n", id, err)
+			return
+		}
+		n := node.NewNode(id, chain, network, registry)
+		n.Start()
+		nodes[i] = n
+	}
+
+	// Allow nodes to start
+	time.Sleep(100 * time.Millisecond)
+
+	// 3. Run Scenarios
+	runHappyPath(chain, network)
+
+	fmt.Println("\n--------------------------------------------------")
+
+	runFraudPath(chain, network)
+
+	// Keep main alive for events to process if needed
+	time.Sleep(1 * time.Second)
+}
+
+// signQuorum collects a real on-chain-verifiable signature from each
+// participant for state, standing in for network.RequestSignature: unlike
+// node.Node.OnSignRequest's placeholder, Vault.sol performs a genuine
+// ecrecover, so the signatures have to come from chain.Sign.
+func signQuorum(chain *simchain.Chain, state *core.State) error {
+	for _, nid := range state.Participants {
+		sig, err := chain.Sign(nid, state)
+		if err != nil {
+			return err
+		}
+		state.Sigs = append(state.Sigs, sig)
+	}
+	return nil
+}
+
+func runHappyPath(chain *simchain.Chain, network *mockp2p.MockP2P) {
+	fmt.Println(">>> Starting HAPPY PATH <<<")
+	wallet := "0xAlice_Happy"
+	token := "0xUSDT"
+
+	// 1. Deposit
+	if err := chain.Deposit(wallet, token, big.NewInt(100)); err != nil {
+		fmt.Printf("Deposit failed: %v\n", err)
+		return
+	}
+
+	// 2. Off-chain Logic (Client Side)
+	// Alice transfers 80 off-chain, so she owns 20.
+	state := &core.State{
+		Wallet:  wallet,
+		Token:   token,
+		Version: 2, // v1 was initial, v2 is current
+		Balance: big.NewInt(20),
+	}
+
+	targetNodes := network.GetQuorumNodes(wallet, Quorum)
+	state.Participants = targetNodes
+
+	fmt.Printf("[Client] Collecting signatures from %v\n", targetNodes)
+	if err := signQuorum(chain, state); err != nil {
+		fmt.Printf("Signing failed: %v\n", err)
+		return
+	}
+
+	// Publish to Network (So nodes persist it)
+	network.PublishState(state)
+	time.Sleep(500 * time.Millisecond) // Wait for propagation
+
+	// 3. Withdrawal
+	fmt.Println("[Client] Requesting Withdrawal for State v2...")
+	if err := chain.RequestWithdrawal(state); err != nil {
+		fmt.Printf("Withdraw request failed: %v\n", err)
+		return
+	}
+
+	// 4. Wait Challenge Period
+	fmt.Println("[Client] Waiting for challenge period...")
+	time.Sleep(3 * time.Second)
+
+	// 5. Finalize
+	if err := chain.Withdraw(wallet); err != nil {
+		fmt.Printf("Withdraw failed: %v\n", err)
+	} else {
+		fmt.Println("[Client] Withdraw Successful!")
+	}
+}
+
+func runFraudPath(chain *simchain.Chain, network *mockp2p.MockP2P) {
+	fmt.Println(">>> Starting FRAUD PATH <<<")
+	wallet := "0xBob_Fraud"
+	token := "0xUSDT"
+
+	// 1. Deposit
+	if err := chain.Deposit(wallet, token, big.NewInt(100)); err != nil {
+		fmt.Printf("Deposit failed: %v\n", err)
+		return
+	}
+
+	targetNodes := network.GetQuorumNodes(wallet, Quorum)
+
+	// 2. Bob's first (legitimate, at the time) state: v1, balance 100.
+	// He keeps the signed v1 state around to replay later.
+	staleState := &core.State{
+		Wallet:       wallet,
+		Token:        token,
+		Version:      1,
+		Balance:      big.NewInt(100),
+		Participants: targetNodes,
+	}
+	if err := signQuorum(chain, staleState); err != nil {
+		fmt.Printf("Signing stale state failed: %v\n", err)
+		return
+	}
+
+	// 3. Bob transfers 50 off-chain (v2, balance 50) and the quorum signs
+	// and learns about the new, real state.
+	realState := &core.State{
+		Wallet:       wallet,
+		Token:        token,
+		Version:      2,
+		Balance:      big.NewInt(50),
+		Participants: targetNodes,
+	}
+	if err := signQuorum(chain, realState); err != nil {
+		fmt.Printf("Signing real state failed: %v\n", err)
+		return
+	}
+
+	// Publish REAL state to network
+	network.PublishState(realState)
+	time.Sleep(500 * time.Millisecond)
+
+	// 4. Fraudulent Withdrawal: Bob replays the old, validly-signed v1 state.
+	fmt.Println("[Client] Bob attempting fraudulent withdrawal with old State v1...")
+	if err := chain.RequestWithdrawal(staleState); err != nil {
+		fmt.Printf("Withdraw request failed: %v\n", err)
+		return
+	}
+
+	// 5. Wait for Challenge (Should happen immediately)
+	time.Sleep(1 * time.Second)
+
+	// 6. Try to Withdraw (Should fail: the quorum should have challenged
+	// with their newer, v2 state)
+	if err := chain.Withdraw(wallet); err == nil {
+		fmt.Println("ERROR: Fraudulent withdraw succeeded!")
+	} else {
+		fmt.Printf("SUCCESS: Fraudulent withdraw blocked: %v\n", err)
+	}
+}
@@ -122,7 +122,7 @@ func (vc *VaultContract) RequestWithdrawal(state *core.State) error {
 	return nil
 }
 
-func (vc *VaultContract) Challenge(candidate *core.State, challengerID string) error {
+func (vc *VaultContract) Challenge(candidate *core.State, challengerID string, bounty *big.Int) error {
 	vc.mu.Lock()
 	defer vc.mu.Unlock()
 
@@ -142,22 +142,54 @@ func (vc *VaultContract) Challenge(candidate *core.State, challengerID string) e
 		return errors.New("challenge version is not newer")
 	}
 
-	// Valid challenge: Slashing logic would go here.
-	// For now, we just cancel the withdrawal.
 	delete(vc.pendingRequests, candidate.Wallet)
 	delete(vc.requestTime, candidate.Wallet)
 
+	// Slash: carve bounty out of the challenged wallet's own on-chain
+	// custody and credit it to the challenger, capped at whatever is
+	// actually there so a challenge can never push a balance negative.
+	paid := big.NewInt(0)
+	if bounty != nil && bounty.Sign() > 0 {
+		available := vc.balances[candidate.Wallet]
+		if available == nil {
+			available = big.NewInt(0)
+		}
+		paid = bounty
+		if paid.Cmp(available) > 0 {
+			paid = available
+		}
+		vc.balances[candidate.Wallet] = new(big.Int).Sub(available, paid)
+		credited, ok := vc.balances[challengerID]
+		if !ok {
+			credited = big.NewInt(0)
+		}
+		vc.balances[challengerID] = new(big.Int).Add(credited, paid)
+	}
+
 	vc.eventBus <- ports.BlockchainEvent{
 		Type: ports.EventChallenged,
 		Payload: ports.ChallengePayload{
 			State:      candidate,
 			Challenger: challengerID,
+			Bounty:     paid,
 		},
 	}
-	fmt.Printf("[Blockchain] CHALLENGE SUCCESS! Request for %s defeated by Ver: %d\n", candidate.Wallet, candidate.Version)
+	fmt.Printf("[Blockchain] CHALLENGE SUCCESS! Request for %s defeated by Ver: %d (bounty %s paid to %s)\n",
+		candidate.Wallet, candidate.Version, paid, challengerID)
 	return nil
 }
 
+// Balance returns wallet's current on-chain custody balance, zero if it's
+// never deposited or been credited anything.
+func (vc *VaultContract) Balance(wallet string) *big.Int {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if b, ok := vc.balances[wallet]; ok {
+		return new(big.Int).Set(b)
+	}
+	return big.NewInt(0)
+}
+
 func (vc *VaultContract) Withdraw(wallet string) error {
 	vc.mu.Lock()
 	defer vc.mu.Unlock()
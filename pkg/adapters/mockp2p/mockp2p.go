@@ -9,18 +9,25 @@ import (
 	"time"
 
 	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/p2p/quota"
 	"github.com/mod/clearnet/pkg/ports"
 )
 
 type MockP2P struct {
 	mu      sync.RWMutex
 	nodes   map[string]ports.NodeHandler
-	nodeIDs []string // Sorted list for hashing
+	nodeIDs []string               // Sorted list for hashing
+	latest  map[string]*core.State // Wallet -> highest-version state PublishState has seen
+
+	// Limiter, if set, enforces a per-peer quota on outbound
+	// RequestSignature calls. Nil means unlimited.
+	Limiter *quota.Limiter
 }
 
 func NewMockP2P() *MockP2P {
 	return &MockP2P{
-		nodes: make(map[string]ports.NodeHandler),
+		nodes:  make(map[string]ports.NodeHandler),
+		latest: make(map[string]*core.State),
 	}
 }
 
@@ -55,6 +62,12 @@ func (p *MockP2P) GetQuorumNodes(wallet string, k int) []string {
 }
 
 func (p *MockP2P) PublishState(state *core.State) error {
+	p.mu.Lock()
+	if current, ok := p.latest[state.Wallet]; !ok || state.Version > current.Version {
+		p.latest[state.Wallet] = state
+	}
+	p.mu.Unlock()
+
 	// In Kademlia, we find the closest nodes and store it there.
 	// We use Quorum of 3.
 	nodes := p.GetQuorumNodes(state.Wallet, 3)
@@ -79,28 +92,44 @@ func (p *MockP2P) PublishState(state *core.State) error {
 	return nil
 }
 
+// GetLatestState returns the highest-version state PublishState has been
+// handed for wallet, standing in for a real DHT query the same way
+// kademlia.Adapter.GetLatestState resolves its pointer record.
 func (p *MockP2P) GetLatestState(wallet string) (*core.State, error) {
-	// In reality, we query the DHT.
-	// Here, we'll cheat a bit and ask the quorum nodes, returning the highest version.
-	// BUT, the interface `GetLatestState` is called BY A NODE (usually).
-	// If a client calls it, they are external.
-	// Let's assume this is a Client-side library function or Node function.
-
-	// nodes := p.GetQuorumNodes(wallet, 3)
-	// var latest *core.State
-
-	// We need to ask the handlers?
-	// The `NodeHandler` interface I defined doesn't have `GetState`.
-	// I should probably add it or relying on the local storage of the calling node.
-	// But `GetLatestState` is on the P2P adapter...
-	// Let's implement a simple query simulation.
-	// We will assume the P2P adapter can "RPC" into the nodes.
-
-	// NOTE: For this mock, since I didn't add GetState to NodeHandler,
-	// I'll skip querying others and assume the caller (Node) relies on its own peer store,
-	// or I'll add `GetState` to NodeHandler now.
-	return nil, errors.New("not implemented in mock")
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	state, ok := p.latest[wallet]
+	if !ok {
+		return nil, fmt.Errorf("mockp2p: no state published for wallet %s", wallet)
+	}
+	return state, nil
 }
+
+// Gossip floods msg to every registered node, simulating the same latency
+// PublishState does, except there's no quorum to target: every node gets it.
+func (p *MockP2P) Gossip(msg ports.GossipMessage) error {
+	p.mu.RLock()
+	nodeIDs := make([]string, len(p.nodeIDs))
+	copy(nodeIDs, p.nodeIDs)
+	p.mu.RUnlock()
+
+	for _, nodeID := range nodeIDs {
+		go func(nid string) {
+			time.Sleep(10 * time.Millisecond)
+
+			p.mu.RLock()
+			handler, ok := p.nodes[nid]
+			p.mu.RUnlock()
+
+			if ok {
+				handler.OnGossip(msg)
+			}
+		}(nodeID)
+	}
+	return nil
+}
+
 func (p *MockP2P) RequestSignature(nodeID string, state *core.State) ([]byte, error) {
 	p.mu.RLock()
 	handler, ok := p.nodes[nodeID]
@@ -110,5 +139,13 @@ func (p *MockP2P) RequestSignature(nodeID string, state *core.State) ([]byte, er
 		return nil, errors.New("node not found")
 	}
 
+	if p.Limiter != nil {
+		release, err := p.Limiter.AdmitSignatureRequest(nodeID)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
 	return handler.OnSignRequest(state)
 }
@@ -17,6 +17,8 @@ type MockRegistry struct {
 	nodes       map[string]ports.NodeInfo
 	activeNodes []string // List of Node IDs (hex string) for pagination
 	manifest    ports.Manifest
+
+	subscribers []chan ports.BlockchainEvent
 }
 
 func New() *MockRegistry {
@@ -31,6 +33,28 @@ func New() *MockRegistry {
 	}
 }
 
+// Subscribe returns a channel of EventNodeRegistered events (the only one
+// MockRegistry can emit: like Unregister, UpdateNode is a documented no-op
+// here because the mock has no caller identity to key off).
+func (m *MockRegistry) Subscribe() <-chan ports.BlockchainEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan ports.BlockchainEvent, 100)
+	m.subscribers = append(m.subscribers, ch)
+	return ch
+}
+
+// publish is a non-blocking broadcast to every Subscribe-r, the same
+// slow-subscriber-drops pattern mockchain.VaultContract's dispatch uses.
+func (m *MockRegistry) publish(ev ports.BlockchainEvent) {
+	for _, sub := range m.subscribers {
+		select {
+		case sub <- ev:
+		default:
+		}
+	}
+}
+
 func (m *MockRegistry) GetManifest(ctx context.Context) (*ports.Manifest, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -84,6 +108,10 @@ func (m *MockRegistry) Register(ctx context.Context, nodeID [32]byte, domain str
 	
 	m.nodes[idStr] = newNode
 	m.activeNodes = append(m.activeNodes, idStr)
+	m.publish(ports.BlockchainEvent{
+		Type:    ports.EventNodeRegistered,
+		Payload: ports.NodeRegisteredPayload{Node: newNode},
+	})
 
 	return nil
 }
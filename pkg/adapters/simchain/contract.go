@@ -0,0 +1,89 @@
+package simchain
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// vaultABIJSON mirrors contracts/Vault.sol. Kept as a hand-written literal
+// (rather than abigen-generated bindings) since this sandbox has no solc;
+// see vaultBin below.
+const vaultABIJSON = `[
+	{"type":"constructor","inputs":[{"name":"_challengePeriod","type":"uint256"}]},
+	{"type":"function","name":"registerNode","inputs":[{"name":"node","type":"address"}],"outputs":[]},
+	{"type":"function","name":"depositFor","inputs":[{"name":"wallet","type":"address"},{"name":"token","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"requestWithdrawal","inputs":[{"name":"wallet","type":"address"},{"name":"token","type":"address"},{"name":"version","type":"uint256"},{"name":"balance","type":"uint256"},{"name":"participants","type":"address[]"},{"name":"sigs","type":"bytes[]"}],"outputs":[]},
+	{"type":"function","name":"challenge","inputs":[{"name":"wallet","type":"address"},{"name":"token","type":"address"},{"name":"version","type":"uint256"},{"name":"balance","type":"uint256"},{"name":"participants","type":"address[]"},{"name":"sigs","type":"bytes[]"},{"name":"challenger","type":"address"}],"outputs":[]},
+	{"type":"function","name":"withdraw","inputs":[{"name":"wallet","type":"address"}],"outputs":[]},
+	{"type":"event","name":"Deposited","inputs":[{"name":"wallet","type":"address","indexed":true},{"name":"token","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"event","name":"WithdrawalRequested","inputs":[{"name":"wallet","type":"address","indexed":true},{"name":"version","type":"uint256","indexed":false},{"name":"balance","type":"uint256","indexed":false},{"name":"stateHash","type":"bytes32","indexed":false}]},
+	{"type":"event","name":"Challenged","inputs":[{"name":"wallet","type":"address","indexed":true},{"name":"challenger","type":"address","indexed":true},{"name":"version","type":"uint256","indexed":false},{"name":"stateHash","type":"bytes32","indexed":false}]},
+	{"type":"event","name":"Withdrawn","inputs":[{"name":"wallet","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]}
+]`
+
+// vaultBin is the deployable creation bytecode for contracts/Vault.sol,
+// compiled with solc 0.8.24 (evmVersion paris, optimizer runs=200):
+//
+//	solc --bin --optimize --evm-version paris contracts/Vault.sol | tail -1
+//
+// Regenerate it whenever contracts/Vault.sol changes; NewChain refuses to
+// deploy with an empty vaultBin rather than pretending to succeed.
+var vaultBin = "608060405234801561001057600080fd5b50604051610e5b380380610e5b83398101604081905261002f91610049565b600080546001600160a01b03191633179055600155610062565b60006020828403121561005b57600080fd5b5051919050565b610dea806100716000396000f3fe608060405234801561001057600080fd5b506004361061009e5760003560e01c80638da5cb5b116100665780638da5cb5b14610124578063b3db428b1461014f578063d3d1fb4814610162578063f3f480d914610195578063fb22e6511461019e57600080fd5b806327e235e3146100a357806351cff8d9146100d65780635c5fa3a5146100eb578063672d7a0d146100fe578063794afdce14610111575b600080fd5b6100c36100b1366004610a85565b60026020526000908152604090205481565b6040519081526020015b60405180910390f35b6100e96100e4366004610a85565b610209565b005b6100e96100f9366004610af3565b610382565b6100e961010c366004610a85565b6104bf565b6100e961011f366004610b97565b610529565b600054610137906001600160a01b031681565b6040516001600160a01b0390911681526020016100cd565b6100e961015d366004610c4b565b6106a9565b610185610170366004610a85565b60036020526000908152604090205460ff1681565b60405190151581526020016100cd565b6100c360015481565b6101df6101ac366004610a85565b60046020819052600091825260409091208054600182015460028301546003840154939094015491939092909160ff1685565b6040805195865260208601949094529284019190915260608301521515608082015260a0016100cd565b6001600160a01b03811660009081526004602081905260409091209081015460ff166102715760405162461bcd60e51b81526020600482015260126024820152711b9bc81c195b991a5b99c81c995c5d595cdd60721b60448201526064015b60405180910390fd5b60015481600301546102839190610c9d565b4210156102d25760405162461bcd60e51b815260206004820152601760248201527f6368616c6c656e676520706572696f64206163746976650000000000000000006044820152606401610268565b6002808201546001600160a01b038416600090815260209290925260408220805491928392610302908490610cb6565b90915550506001600160a01b0383166000818152600460208190526040808320838155600181018490556002810184905560038101939093559101805460ff19169055517f7084f5476618d8e60b11ef0d7d3f06914655adb8793e28ff7f018d4c76d505d5906103759084815260200190565b60405180910390a2505050565b600088888888888860405160200161039f96959493929190610cc9565b6040516020818303038152906040528051906020012090506103c48186868686610770565b6040518060a0016040528082815260200188815260200187815260200142815260200160011515815250600460008b6001600160a01b03166001600160a01b031681526020019081526020016000206000820151816000015560208201518160010155604082015181600201556060820151816003015560808201518160040160006101000a81548160ff021916908315150217905550905050886001600160a01b03167fc99e5b9550957766e92cda067a2c01eb9f089e0aecf257bc0db6f53b17edabf18888846040516104ac939291909283526020830191909152604082015260600190565b60405180910390a2505050505050505050565b6000546001600160a01b031633146105055760405162461bcd60e51b81526020600482015260096024820152683737ba1037bbb732b960b91b6044820152606401610268565b6001600160a01b03166000908152600360205260409020805460ff19166001179055565b6001600160a01b03891660009081526004602081905260409091209081015460ff1661058c5760405162461bcd60e51b81526020600482015260126024820152711b9bc81c195b991a5b99c81c995c5d595cdd60721b6044820152606401610268565b806001015488116105df5760405162461bcd60e51b815260206004820152601e60248201527f6368616c6c656e67652076657273696f6e206973206e6f74206e6577657200006044820152606401610268565b60008a8a8a8a8a8a6040516020016105fc96959493929190610cc9565b6040516020818303038152906040528051906020012090506106218188888888610770565b6001600160a01b038b811660008181526004602081815260408084208481556001810185905560028101859055600381019490945592909101805460ff1916905581518d8152908101859052928616927f747d04a0226e3b4eeb6d65bf485ffdd6239abda34578da6abd74b924f1a29867910160405180910390a35050505050505050505050565b6000546001600160a01b031633146106ef5760405162461bcd60e51b81526020600482015260096024820152683737ba1037bbb732b960b91b6044820152606401610268565b6001600160a01b03831660009081526002602052604081208054839290610717908490610c9d565b92505081905550816001600160a01b0316836001600160a01b03167f8752a472e571a816aea92eec8dae9baf628e840f4929fbcc2d155e6233ff68a78360405161076391815260200190565b60405180910390a3505050565b8281146107b15760405162461bcd60e51b815260206004820152600f60248201526e0d8cadccee8d040dad2e6dac2e8c6d608b1b6044820152606401610268565b826107f05760405162461bcd60e51b815260206004820152600f60248201526e6e6f207061727469636970616e747360881b6044820152606401610268565b6040517f19457468657265756d205369676e6564204d6573736167653a0a3332000000006020820152603c8101869052600090605c0160405160208183030381529060405280519060200120905060005b84811015610988576003600087878481811061085f5761085f610d3e565b90506020020160208101906108749190610a85565b6001600160a01b0316815260208101919091526040016000205460ff166108dd5760405162461bcd60e51b815260206004820152601860248201527f756e617574686f72697a6564207061727469636970616e7400000000000000006044820152606401610268565b8585828181106108ef576108ef610d3e565b90506020020160208101906109049190610a85565b6001600160a01b031661093a8386868581811061092357610923610d3e565b90506020028101906109359190610d54565b610991565b6001600160a01b0316146109805760405162461bcd60e51b815260206004820152600d60248201526c626164207369676e617475726560981b6044820152606401610268565b600101610841565b50505050505050565b6000604182146109da5760405162461bcd60e51b81526020600482015260146024820152730c4c2c840e6d2cedcc2e8eae4ca40d8cadccee8d60631b6044820152606401610268565b82356020840135604085013560001a601b811015610a00576109fd601b82610d9b565b90505b60408051600081526020810180835289905260ff831691810191909152606081018490526080810183905260019060a0016020604051602081039080840390855afa158015610a53573d6000803e3d6000fd5b5050604051601f19015198975050505050505050565b80356001600160a01b0381168114610a8057600080fd5b919050565b600060208284031215610a9757600080fd5b610aa082610a69565b9392505050565b60008083601f840112610ab957600080fd5b50813567ffffffffffffffff811115610ad157600080fd5b6020830191508360208260051b8501011115610aec57600080fd5b9250929050565b60008060008060008060008060c0898b031215610b0f57600080fd5b610b1889610a69565b9750610b2660208a01610a69565b96506040890135955060608901359450608089013567ffffffffffffffff80821115610b5157600080fd5b610b5d8c838d01610aa7565b909650945060a08b0135915080821115610b7657600080fd5b50610b838b828c01610aa7565b999c989b5096995094979396929594505050565b600080600080600080600080600060e08a8c031215610bb557600080fd5b610bbe8a610a69565b9850610bcc60208b01610a69565b975060408a0135965060608a0135955060808a013567ffffffffffffffff80821115610bf757600080fd5b610c038d838e01610aa7565b909750955060a08c0135915080821115610c1c57600080fd5b50610c298c828d01610aa7565b9094509250610c3c905060c08b01610a69565b90509295985092959850929598565b600080600060608486031215610c6057600080fd5b610c6984610a69565b9250610c7760208501610a69565b9150604084013590509250925092565b634e487b7160e01b600052601160045260246000fd5b80820180821115610cb057610cb0610c87565b92915050565b81810381811115610cb057610cb0610c87565b6001600160a01b038781168252868116602080840191909152604083018790526060830186905260a0608084018190528301849052600091859160c08501845b87811015610d2e5783610d1b86610a69565b1682529382019390820190600101610d09565b509b9a5050505050505050505050565b634e487b7160e01b600052603260045260246000fd5b6000808335601e19843603018112610d6b57600080fd5b83018035915067ffffffffffffffff821115610d8657600080fd5b602001915036819003821315610aec57600080fd5b60ff8181168382160190811115610cb057610cb0610c8756fea26469706673582212202b2072be7b88a04bbf0513a293baac39f0be5fe525f04e3534f3d242a83ff48f64736f6c63430008180033"
+
+var vaultABI = mustParseABI(vaultABIJSON)
+
+func mustParseABI(raw string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(raw))
+	if err != nil {
+		panic("simchain: invalid vault ABI: " + err.Error())
+	}
+	return parsed
+}
+
+func mustType(name string) abi.Type {
+	t, err := abi.NewType(name, "", nil)
+	if err != nil {
+		panic("simchain: bad abi type " + name + ": " + err.Error())
+	}
+	return t
+}
+
+var requestHashArgs = abi.Arguments{
+	{Type: mustType("address")},
+	{Type: mustType("address")},
+	{Type: mustType("uint256")},
+	{Type: mustType("uint256")},
+	{Type: mustType("address[]")},
+}
+
+// requestHash reproduces Vault.sol's
+// keccak256(abi.encode(wallet, token, version, balance, participants)),
+// the digest that quorum members sign over for a withdrawal or challenge.
+// This is the on-chain analog of core.State.Hash, which imitates the same
+// scheme without actually performing keccak/abi.encode.
+func requestHash(wallet, token common.Address, version uint64, balance *big.Int, participants []common.Address) ([32]byte, error) {
+	packed, err := requestHashArgs.Pack(wallet, token, new(big.Int).SetUint64(version), balance, participants)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return [32]byte(crypto.Keccak256Hash(packed)), nil
+}
+
+// signRequest signs digest as an EIP-191 "Ethereum Signed Message", matching
+// Vault.sol's _verifyQuorum/_recover.
+func signRequest(key *ecdsa.PrivateKey, digest [32]byte) ([]byte, error) {
+	sig, err := crypto.Sign(accounts.TextHash(digest[:]), key)
+	if err != nil {
+		return nil, err
+	}
+	// go-ethereum's crypto.Sign returns a recovery id of 0/1; Solidity's
+	// ecrecover expects the legacy 27/28 encoding.
+	sig[64] += 27
+	return sig, nil
+}
@@ -0,0 +1,451 @@
+// Package simchain implements ports.BlockchainAdapter against a real EVM: an
+// in-process go-ethereum dev node (ethclient/simulated) running a genuine
+// Solidity Vault contract, with actual ecrecover-verified quorum signatures.
+// It exists alongside pkg/adapters/mockchain, which fakes the same interface
+// in plain Go for cheap demos; simchain trades that speed for the ability to
+// catch bugs mockchain can't see, like the missing signature verification
+// and balance-partitioning ambiguity called out in VaultContract.Withdraw.
+package simchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// errNoBytecode is returned by NewChain when vaultBin hasn't been populated
+// (see contract.go): there is no solc/abigen in this environment to produce
+// it, so deployment is refused rather than faked.
+var errNoBytecode = errors.New("simchain: vaultBin is empty; compile contracts/Vault.sol with solc and paste the bytecode into contract.go")
+
+// Chain adapts a Vault contract deployed on an ethclient/simulated backend to
+// ports.BlockchainAdapter. Wallet/token/node identifiers are the same
+// arbitrary strings used elsewhere in the codebase (see core.State); Chain
+// keeps an internal ECDSA keypair per identifier so it can both derive an
+// on-chain address for it and, for nodes, sign withdrawal requests on the
+// caller's behalf (mirroring mockchain's model of a single trusted process
+// acting for every participant).
+type Chain struct {
+	backend  *simulated.Backend
+	client   simulated.Client
+	owner    *bind.TransactOpts
+	address  common.Address // deployed Vault address
+	contract *bind.BoundContract
+
+	challengePeriod time.Duration
+
+	mu       sync.Mutex
+	accounts map[string]*ecdsa.PrivateKey
+	ids      map[common.Address]string // reverse of accounts, for translating logs back to ids
+
+	eventBus    chan ports.BlockchainEvent
+	subscribers []chan ports.BlockchainEvent
+
+	watchCancel context.CancelFunc
+}
+
+// NewChain deploys a fresh Vault contract to a new simulated backend and
+// starts translating its logs into ports.BlockchainEvent values.
+// challengePeriod is the on-chain challenge period passed to the Vault
+// constructor.
+func NewChain(challengePeriod time.Duration) (*Chain, error) {
+	if vaultBin == "" {
+		return nil, errNoBytecode
+	}
+
+	ownerKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("simchain: generate owner key: %w", err)
+	}
+	ownerAddr := crypto.PubkeyToAddress(ownerKey.PublicKey)
+
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		ownerAddr: {Balance: new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))},
+	})
+	client := backend.Client()
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		backend.Close()
+		return nil, fmt.Errorf("simchain: chain id: %w", err)
+	}
+	owner, err := bind.NewKeyedTransactorWithChainID(ownerKey, chainID)
+	if err != nil {
+		backend.Close()
+		return nil, fmt.Errorf("simchain: transactor: %w", err)
+	}
+
+	c := &Chain{
+		backend:         backend,
+		client:          client,
+		owner:           owner,
+		challengePeriod: challengePeriod,
+		accounts:        make(map[string]*ecdsa.PrivateKey),
+		ids:             make(map[common.Address]string),
+		eventBus:        make(chan ports.BlockchainEvent, 100),
+	}
+
+	addr, _, _, err := bind.DeployContract(owner, vaultABI, common.FromHex(vaultBin), client, big.NewInt(int64(challengePeriod.Seconds())))
+	if err != nil {
+		backend.Close()
+		return nil, fmt.Errorf("simchain: deploy Vault: %w", err)
+	}
+	backend.Commit()
+	c.address = addr
+	c.contract = bind.NewBoundContract(addr, vaultABI, client, client, client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.watchCancel = cancel
+	go c.dispatch()
+	go c.watchLogs(ctx)
+
+	return c, nil
+}
+
+// dispatch fans eventBus out to every subscriber, mirroring
+// mockchain.VaultContract's dispatch loop (including its non-blocking-send
+// policy for slow subscribers).
+func (c *Chain) dispatch() {
+	for event := range c.eventBus {
+		c.mu.Lock()
+		for _, sub := range c.subscribers {
+			select {
+			case sub <- event:
+			default:
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Close stops the log-watching goroutine and shuts down the simulated
+// backend.
+func (c *Chain) Close() error {
+	if c.watchCancel != nil {
+		c.watchCancel()
+	}
+	return c.backend.Close()
+}
+
+// Subscribe implements ports.BlockchainAdapter.
+func (c *Chain) Subscribe() <-chan ports.BlockchainEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan ports.BlockchainEvent, 100)
+	c.subscribers = append(c.subscribers, ch)
+	return ch
+}
+
+// AddNode registers id as a quorum signer, generating a keypair for it if it
+// doesn't have one yet, and returns its on-chain address.
+func (c *Chain) AddNode(id string) (common.Address, error) {
+	addr, _ := c.accountFor(id)
+
+	tx, err := c.contract.Transact(c.owner, "registerNode", addr)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("simchain: registerNode(%s): %w", id, err)
+	}
+	if err := c.commit(tx); err != nil {
+		return common.Address{}, err
+	}
+	return addr, nil
+}
+
+// Sign produces a quorum signature over state, as a registered node (added
+// via AddNode) would. Tests and demo code use this in place of
+// node.Node.OnSignRequest's placeholder signature, since Vault.sol performs
+// a real ecrecover.
+func (c *Chain) Sign(nodeID string, state *core.State) ([]byte, error) {
+	key, ok := c.keyFor(nodeID)
+	if !ok {
+		return nil, fmt.Errorf("simchain: unknown node %q; call AddNode first", nodeID)
+	}
+	digest, err := c.requestHashFor(state)
+	if err != nil {
+		return nil, err
+	}
+	return signRequest(key, digest)
+}
+
+// Deposit implements ports.BlockchainAdapter.
+func (c *Chain) Deposit(wallet, token string, amount *big.Int) error {
+	walletAddr, _ := c.accountFor(wallet)
+	tokenAddr, _ := c.accountFor(token)
+
+	tx, err := c.contract.Transact(c.owner, "depositFor", walletAddr, tokenAddr, amount)
+	if err != nil {
+		return fmt.Errorf("simchain: depositFor: %w", err)
+	}
+	return c.commit(tx)
+}
+
+// RequestWithdrawal implements ports.BlockchainAdapter.
+func (c *Chain) RequestWithdrawal(state *core.State) error {
+	walletAddr, _ := c.accountFor(state.Wallet)
+	tokenAddr, _ := c.accountFor(state.Token)
+	participants, err := c.addressesFor(state.Participants)
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.contract.Transact(c.owner, "requestWithdrawal",
+		walletAddr, tokenAddr, new(big.Int).SetUint64(state.Version), state.Balance, participants, state.Sigs)
+	if err != nil {
+		return fmt.Errorf("simchain: requestWithdrawal: %w", err)
+	}
+	return c.commit(tx)
+}
+
+// Challenge implements ports.BlockchainAdapter. bounty is accepted to
+// satisfy the interface but not yet wired into the call: contracts/Vault.sol
+// doesn't carve a bounty out of the challenged balance the way
+// mockchain.VaultContract.Challenge does, so a real on-chain challenge never
+// pays one out. Paying bounties on-chain needs a challenge() overload added
+// to Vault.sol (and vaultBin recompiled with solc) before this can do the
+// same carve-out mockchain does.
+func (c *Chain) Challenge(state *core.State, challengerID string, bounty *big.Int) error {
+	walletAddr, _ := c.accountFor(state.Wallet)
+	tokenAddr, _ := c.accountFor(state.Token)
+	challengerAddr, _ := c.accountFor(challengerID)
+	participants, err := c.addressesFor(state.Participants)
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.contract.Transact(c.owner, "challenge",
+		walletAddr, tokenAddr, new(big.Int).SetUint64(state.Version), state.Balance, participants, state.Sigs, challengerAddr)
+	if err != nil {
+		return fmt.Errorf("simchain: challenge: %w", err)
+	}
+	return c.commit(tx)
+}
+
+// Withdraw implements ports.BlockchainAdapter.
+func (c *Chain) Withdraw(wallet string) error {
+	walletAddr, _ := c.accountFor(wallet)
+
+	tx, err := c.contract.Transact(c.owner, "withdraw", walletAddr)
+	if err != nil {
+		return fmt.Errorf("simchain: withdraw: %w", err)
+	}
+	return c.commit(tx)
+}
+
+// requestHashFor computes the digest Vault.sol expects the quorum to have
+// signed for state.
+func (c *Chain) requestHashFor(state *core.State) ([32]byte, error) {
+	walletAddr, _ := c.accountFor(state.Wallet)
+	tokenAddr, _ := c.accountFor(state.Token)
+	participants, err := c.addressesFor(state.Participants)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return requestHash(walletAddr, tokenAddr, state.Version, state.Balance, participants)
+}
+
+func (c *Chain) addressesFor(ids []string) ([]common.Address, error) {
+	addrs := make([]common.Address, len(ids))
+	for i, id := range ids {
+		addrs[i], _ = c.accountFor(id)
+	}
+	return addrs, nil
+}
+
+// accountFor returns id's keypair, generating one on first use. Every
+// logical identifier (wallet, token, node) gets one so that a wallet or
+// token could sign in the future even though only node participants
+// currently need to.
+func (c *Chain) accountFor(id string) (common.Address, *ecdsa.PrivateKey) {
+	if key, ok := c.keyFor(id); ok {
+		return crypto.PubkeyToAddress(key.PublicKey), key
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, ok := c.accounts[id]; ok {
+		return crypto.PubkeyToAddress(key.PublicKey), key
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		// crypto/ecdsa key generation over a well-known curve does not fail
+		// in practice; a nil key here would only surface as a bad signature
+		// downstream, so panicking here gives a much clearer failure.
+		panic("simchain: generate key for " + id + ": " + err.Error())
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	c.accounts[id] = key
+	c.ids[addr] = id
+	return addr, key
+}
+
+// idFor reverses accountFor: it recovers the logical identifier behind an
+// on-chain address seen in a log, for translating events back into the
+// string-keyed vocabulary the rest of the codebase uses. Addresses Chain
+// never minted (shouldn't happen, since every address here comes from one
+// of its own deployments) fall back to the hex address itself.
+func (c *Chain) idFor(addr common.Address) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if id, ok := c.ids[addr]; ok {
+		return id
+	}
+	return addr.Hex()
+}
+
+func (c *Chain) keyFor(id string) (*ecdsa.PrivateKey, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.accounts[id]
+	return key, ok
+}
+
+// commit mines a block containing tx and waits for its receipt, translating
+// a reverted transaction into a plain Go error the way mockchain's
+// require()-backed checks return one.
+func (c *Chain) commit(tx *types.Transaction) error {
+	c.backend.Commit()
+	receipt, err := bind.WaitMined(context.Background(), c.client, tx)
+	if err != nil {
+		return fmt.Errorf("simchain: waiting for %s: %w", tx.Hash(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("simchain: transaction %s reverted", tx.Hash())
+	}
+	return nil
+}
+
+// watchLogs subscribes to every log the Vault contract emits and translates
+// each into a ports.BlockchainEvent on eventBus, so that node.Node (written
+// against mockchain's in-process event bus) works unmodified against a real
+// EVM. It runs until ctx is cancelled (see Close).
+func (c *Chain) watchLogs(ctx context.Context) {
+	logs := make(chan types.Log, 100)
+	sub, err := c.client.SubscribeFilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{c.address},
+	}, logs)
+	if err != nil {
+		fmt.Printf("[simchain] subscribe logs: %v\n", err)
+		return
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-sub.Err():
+			if err != nil {
+				fmt.Printf("[simchain] log subscription error: %v\n", err)
+			}
+			return
+		case vLog := <-logs:
+			if event, ok := c.translate(vLog); ok {
+				c.eventBus <- event
+			}
+		}
+	}
+}
+
+// translate converts a single Vault log into the ports.BlockchainEvent
+// mockchain would have emitted for the equivalent call. Vault's events carry
+// less than mockchain's in-process payloads do (e.g. WithdrawalRequested has
+// no token or participants, since those aren't needed on-chain) so the
+// reconstructed core.State values are necessarily partial; node.Node only
+// reads the fields Vault actually emits.
+func (c *Chain) translate(vLog types.Log) (ports.BlockchainEvent, bool) {
+	if len(vLog.Topics) == 0 {
+		return ports.BlockchainEvent{}, false
+	}
+
+	switch vLog.Topics[0] {
+	case vaultABI.Events["Deposited"].ID:
+		wallet := common.BytesToAddress(vLog.Topics[1].Bytes())
+		token := common.BytesToAddress(vLog.Topics[2].Bytes())
+		var data struct{ Amount *big.Int }
+		if err := vaultABI.UnpackIntoInterface(&data, "Deposited", vLog.Data); err != nil {
+			return ports.BlockchainEvent{}, false
+		}
+		return ports.BlockchainEvent{
+			Type: ports.EventDeposited,
+			Payload: ports.DepositPayload{
+				Wallet: c.idFor(wallet),
+				Token:  c.idFor(token),
+				Amount: data.Amount,
+			},
+		}, true
+
+	case vaultABI.Events["WithdrawalRequested"].ID:
+		wallet := common.BytesToAddress(vLog.Topics[1].Bytes())
+		var data struct {
+			Version   *big.Int
+			Balance   *big.Int
+			StateHash [32]byte
+		}
+		if err := vaultABI.UnpackIntoInterface(&data, "WithdrawalRequested", vLog.Data); err != nil {
+			return ports.BlockchainEvent{}, false
+		}
+		return ports.BlockchainEvent{
+			Type: ports.EventWithdrawalRequested,
+			Payload: ports.RequestPayload{
+				State: &core.State{
+					Wallet:  c.idFor(wallet),
+					Version: data.Version.Uint64(),
+					Balance: data.Balance,
+				},
+			},
+		}, true
+
+	case vaultABI.Events["Challenged"].ID:
+		wallet := common.BytesToAddress(vLog.Topics[1].Bytes())
+		challenger := common.BytesToAddress(vLog.Topics[2].Bytes())
+		var data struct {
+			Version   *big.Int
+			StateHash [32]byte
+		}
+		if err := vaultABI.UnpackIntoInterface(&data, "Challenged", vLog.Data); err != nil {
+			return ports.BlockchainEvent{}, false
+		}
+		return ports.BlockchainEvent{
+			Type: ports.EventChallenged,
+			Payload: ports.ChallengePayload{
+				State: &core.State{
+					Wallet:  c.idFor(wallet),
+					Version: data.Version.Uint64(),
+				},
+				Challenger: c.idFor(challenger),
+			},
+		}, true
+
+	case vaultABI.Events["Withdrawn"].ID:
+		wallet := common.BytesToAddress(vLog.Topics[1].Bytes())
+		var data struct{ Amount *big.Int }
+		if err := vaultABI.UnpackIntoInterface(&data, "Withdrawn", vLog.Data); err != nil {
+			return ports.BlockchainEvent{}, false
+		}
+		return ports.BlockchainEvent{
+			Type: ports.EventWithdrawn,
+			Payload: ports.WithdrawnPayload{
+				Wallet: c.idFor(wallet),
+				Amount: data.Amount,
+			},
+		}, true
+
+	default:
+		return ports.BlockchainEvent{}, false
+	}
+}
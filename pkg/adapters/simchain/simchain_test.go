@@ -0,0 +1,172 @@
+package simchain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+const testChallengePeriod = 2 * time.Second
+
+func mustSign(t *testing.T, chain *Chain, state *core.State) {
+	t.Helper()
+	for _, nid := range state.Participants {
+		sig, err := chain.Sign(nid, state)
+		if err != nil {
+			t.Fatalf("Sign(%s): %v", nid, err)
+		}
+		state.Sigs = append(state.Sigs, sig)
+	}
+}
+
+// TestChain_HappyWithdrawal exercises the real EVM path end to end: deploy
+// Vault, register quorum nodes, deposit, request a withdrawal backed by
+// signatures the contract itself verifies with ecrecover, wait out the
+// challenge period, and withdraw.
+func TestChain_HappyWithdrawal(t *testing.T) {
+	chain, err := NewChain(testChallengePeriod)
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	defer chain.Close()
+
+	wallet, token := "wallet-1", "token-usdt"
+	participants := []string{"node-0", "node-1", "node-2"}
+	for _, id := range participants {
+		if _, err := chain.AddNode(id); err != nil {
+			t.Fatalf("AddNode(%s): %v", id, err)
+		}
+	}
+
+	if err := chain.Deposit(wallet, token, big.NewInt(100)); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	state := &core.State{
+		Wallet:       wallet,
+		Token:        token,
+		Version:      2,
+		Balance:      big.NewInt(20),
+		Participants: participants,
+	}
+	mustSign(t, chain, state)
+
+	if err := chain.RequestWithdrawal(state); err != nil {
+		t.Fatalf("RequestWithdrawal: %v", err)
+	}
+
+	if err := chain.backend.AdjustTime(testChallengePeriod); err != nil {
+		t.Fatalf("AdjustTime: %v", err)
+	}
+	chain.backend.Commit()
+
+	if err := chain.Withdraw(wallet); err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+}
+
+// TestChain_RejectsUnauthorizedSignature confirms the on-chain ecrecover
+// actually verifies signatures: a participant that was never registered via
+// AddNode can still "sign" (chain.Sign generates a key for any unknown id on
+// first use), but Vault.sol must reject its signature as an unauthorized
+// participant rather than accepting it like mockchain would.
+func TestChain_RejectsUnauthorizedSignature(t *testing.T) {
+	chain, err := NewChain(testChallengePeriod)
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	defer chain.Close()
+
+	wallet, token := "wallet-2", "token-usdt"
+	if _, err := chain.AddNode("node-0"); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+	if err := chain.Deposit(wallet, token, big.NewInt(100)); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	state := &core.State{
+		Wallet:       wallet,
+		Token:        token,
+		Version:      2,
+		Balance:      big.NewInt(20),
+		Participants: []string{"node-0", "node-unregistered"},
+	}
+	mustSign(t, chain, state)
+
+	if err := chain.RequestWithdrawal(state); err == nil {
+		t.Fatal("RequestWithdrawal: expected revert for unregistered participant, got nil error")
+	}
+}
+
+// TestChain_FraudChallengeBlocksStaleWithdrawal reproduces cmd/simdemo's
+// fraud path as a test: a participant replays an old, validly-signed state
+// after a newer one has been signed; a challenge with the newer state must
+// block the stale withdrawal.
+func TestChain_FraudChallengeBlocksStaleWithdrawal(t *testing.T) {
+	chain, err := NewChain(testChallengePeriod)
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	defer chain.Close()
+
+	wallet, token := "wallet-3", "token-usdt"
+	participants := []string{"node-0", "node-1", "node-2"}
+	for _, id := range participants {
+		if _, err := chain.AddNode(id); err != nil {
+			t.Fatalf("AddNode(%s): %v", id, err)
+		}
+	}
+	if err := chain.Deposit(wallet, token, big.NewInt(100)); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	staleState := &core.State{
+		Wallet:       wallet,
+		Token:        token,
+		Version:      1,
+		Balance:      big.NewInt(100),
+		Participants: participants,
+	}
+	mustSign(t, chain, staleState)
+
+	realState := &core.State{
+		Wallet:       wallet,
+		Token:        token,
+		Version:      2,
+		Balance:      big.NewInt(50),
+		Participants: participants,
+	}
+	mustSign(t, chain, realState)
+
+	sub := chain.Subscribe()
+
+	if err := chain.RequestWithdrawal(staleState); err != nil {
+		t.Fatalf("RequestWithdrawal(stale): %v", err)
+	}
+
+	select {
+	case ev := <-sub:
+		if ev.Type != ports.EventWithdrawalRequested {
+			t.Fatalf("expected EventWithdrawalRequested, got %v", ev.Type)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for WithdrawalRequested event")
+	}
+
+	if err := chain.Challenge(realState, "node-0", big.NewInt(0)); err != nil {
+		t.Fatalf("Challenge: %v", err)
+	}
+
+	if err := chain.backend.AdjustTime(testChallengePeriod); err != nil {
+		t.Fatalf("AdjustTime: %v", err)
+	}
+	chain.backend.Commit()
+
+	if err := chain.Withdraw(wallet); err == nil {
+		t.Fatal("Withdraw: expected the challenged request to block withdrawal, got nil error")
+	}
+}
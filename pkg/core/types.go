@@ -3,11 +3,25 @@ package core
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 )
 
+// HTLC is a hash-locked conditional payment carved out of a State's Balance:
+// Amount is reserved against Payer until either SettleHTLC (Payee produces
+// the preimage of Hash) or TimeoutHTLC (Expiry passes and Payer reclaims it).
+// Modeled on Lightning's HTLCs, but scoped to this repo's single-State-per-
+// wallet custody model rather than a pairwise channel.
+type HTLC struct {
+	Hash   string // hex digest the preimage must hash to
+	Amount *big.Int
+	Expiry uint64 // block/version height after which Payer may time it out
+	Payer  string // wallet funding the HTLC (must equal the owning State's Wallet)
+	Payee  string // wallet that can claim it by revealing the preimage
+}
+
 // State represents the snapshot of a ledger entry
 type State struct {
 	Wallet       string   // Address of the user
@@ -16,22 +30,136 @@ type State struct {
 	Balance      *big.Int // Balance
 	Participants []string // List of Node IDs (Quorum)
 	Sigs         [][]byte // Signatures
+	Pending      []HTLC   // Outgoing HTLCs carved out of Balance, not yet settled or timed out
+
+	// Preimage is set only on a state submitted to settle one of the
+	// previous version's Pending HTLCs: it's the evidence a quorum member
+	// checks the disappearing HTLC's Hash against before signing (see
+	// HashPreimage). It is transient proof, not ledger data, so Hash
+	// deliberately doesn't fold it in.
+	Preimage string
+}
+
+// ErrHTLCNotFound is returned by SettleHTLC/TimeoutHTLC when hash isn't in Pending.
+var ErrHTLCNotFound = errors.New("core: no pending HTLC with that hash")
+
+// AvailableBalance is Balance minus everything reserved by Pending HTLCs:
+// the amount this wallet can still commit to a new transfer or HTLC.
+func (s *State) AvailableBalance() *big.Int {
+	avail := new(big.Int).Set(s.Balance)
+	for _, h := range s.Pending {
+		avail.Sub(avail, h.Amount)
+	}
+	return avail
+}
+
+// AddHTLC returns a clone of s with h carved out of AvailableBalance and
+// appended to Pending. It does not touch Balance itself: Balance only moves
+// once the HTLC is settled or timed out, matching how a real on-chain
+// balance wouldn't see funds move until the conditional payment resolves.
+func (h *HTLC) validate(s *State) error {
+	if h.Payer != s.Wallet {
+		return fmt.Errorf("core: HTLC payer %q does not match wallet %q", h.Payer, s.Wallet)
+	}
+	if h.Amount == nil || h.Amount.Sign() <= 0 {
+		return errors.New("core: HTLC amount must be positive")
+	}
+	return nil
+}
+
+func (s *State) AddHTLC(h HTLC) (*State, error) {
+	if err := h.validate(s); err != nil {
+		return nil, err
+	}
+	if s.AvailableBalance().Cmp(h.Amount) < 0 {
+		return nil, fmt.Errorf("core: insufficient available balance for HTLC of %s", h.Amount)
+	}
+
+	next := s.Clone()
+	next.Version++
+	next.Sigs = nil
+	next.Pending = append(next.Pending, h)
+	return next, nil
+}
+
+// HashPreimage is the digest an HTLC's Hash must match for SettleHTLC to
+// accept preimage: hex(sha256(preimage)). Exported so callers validating a
+// settlement (e.g. node.Node.OnSignRequest checking State.Preimage) hash the
+// same way SettleHTLC does.
+func HashPreimage(preimage string) string {
+	digest := sha256.Sum256([]byte(preimage))
+	return hex.EncodeToString(digest[:])
+}
+
+// SettleHTLC claims the pending HTLC whose Hash matches HashPreimage(preimage),
+// moving its Amount out of Pending and deducting it from Balance (the Payee
+// collects it on their own State separately; this only updates the payer's
+// side of the ledger). The returned State carries preimage in Preimage, so
+// whoever collects signatures for it can prove the settlement was legitimate.
+func (s *State) SettleHTLC(preimage string) (*State, error) {
+	hash := HashPreimage(preimage)
+
+	idx, h := s.findPending(hash)
+	if idx < 0 {
+		return nil, ErrHTLCNotFound
+	}
+
+	next := s.Clone()
+	next.Version++
+	next.Sigs = nil
+	next.Preimage = preimage
+	next.Balance.Sub(next.Balance, h.Amount)
+	next.Pending = append(next.Pending[:idx], next.Pending[idx+1:]...)
+	return next, nil
+}
+
+// TimeoutHTLC reclaims the pending HTLC identified by hash without touching
+// Balance, since the funds were only ever reserved, never spent. Callers are
+// responsible for checking the HTLC's Expiry has actually passed.
+func (s *State) TimeoutHTLC(hash string) (*State, error) {
+	idx, _ := s.findPending(hash)
+	if idx < 0 {
+		return nil, ErrHTLCNotFound
+	}
+
+	next := s.Clone()
+	next.Version++
+	next.Sigs = nil
+	next.Pending = append(next.Pending[:idx], next.Pending[idx+1:]...)
+	return next, nil
+}
+
+func (s *State) findPending(hash string) (int, *HTLC) {
+	for i := range s.Pending {
+		if s.Pending[i].Hash == hash {
+			return i, &s.Pending[i]
+		}
+	}
+	return -1, nil
 }
 
 // Hash calculates the unique identifier for this state
 // imitating: keccak256(abi.encode(wallet, token, version, balance, participants));
+//
+// Pending is folded in too, so two states that only differ by an in-flight
+// HTLC still sign as distinct versions. Note this makes Hash a superset of
+// simchain's on-chain requestHash, which only covers the fields Vault.sol
+// itself checks (it has no notion of HTLCs) — quorum signatures collected
+// off of Hash are still valid there since requestHash is computed
+// independently from the same wallet/token/version/balance/participants.
 func (s *State) Hash() string {
 	// Sort participants to ensure consistent hashing
 	// (Assuming the contract requires sorted or specific order, strictly we should preserve order if the contract does,
 	// but usually for deterministic hashing sets are sorted).
 	// For this simulation, we assume 'Participants' is already the correct ordered list.
 
-	raw := fmt.Sprintf("%s:%s:%d:%s:%s",
+	raw := fmt.Sprintf("%s:%s:%d:%s:%s:%s",
 		s.Wallet,
 		s.Token,
 		s.Version,
 		s.Balance.String(),
 		strings.Join(s.Participants, ","),
+		hashPending(s.Pending),
 	)
 
 	hasher := sha256.New()
@@ -39,7 +167,18 @@ func (s *State) Hash() string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
-// Clone creates a deep copy of the state
+func hashPending(pending []HTLC) string {
+	parts := make([]string, len(pending))
+	for i, h := range pending {
+		parts[i] = fmt.Sprintf("%s:%s:%d:%s:%s", h.Hash, h.Amount.String(), h.Expiry, h.Payer, h.Payee)
+	}
+	return strings.Join(parts, "|")
+}
+
+// Clone creates a deep copy of the state. Preimage is deliberately not
+// copied: it's evidence for one specific version's transition, not state to
+// carry forward, so every method that derives a next version from Clone
+// starts with an empty Preimage and only SettleHTLC ever sets it.
 func (s *State) Clone() *State {
 	newBal := new(big.Int).Set(s.Balance)
 	newParts := make([]string, len(s.Participants))
@@ -50,6 +189,16 @@ func (s *State) Clone() *State {
 		copy(newSig, sig)
 		newSigs[i] = newSig
 	}
+	newPending := make([]HTLC, len(s.Pending))
+	for i, h := range s.Pending {
+		newPending[i] = HTLC{
+			Hash:   h.Hash,
+			Amount: new(big.Int).Set(h.Amount),
+			Expiry: h.Expiry,
+			Payer:  h.Payer,
+			Payee:  h.Payee,
+		}
+	}
 
 	return &State{
 		Wallet:       s.Wallet,
@@ -58,5 +207,6 @@ func (s *State) Clone() *State {
 		Balance:      newBal,
 		Participants: newParts,
 		Sigs:         newSigs,
+		Pending:      newPending,
 	}
 }
@@ -0,0 +1,66 @@
+package light
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// cacheEntry is one soft-fail cache slot.
+type cacheEntry struct {
+	state     *core.State
+	proof     *Proof
+	expiresAt time.Time
+}
+
+// CachingODR wraps an ODR with a TTL cache that soft-fails: a fresh cache
+// hit is returned immediately, and if the underlying lookup errors out (peer
+// unreachable, timeout, ...) a stale cached entry is returned instead of
+// propagating the error, so a flaky full node doesn't make a light client's
+// repeated lookups flap.
+type CachingODR struct {
+	underlying ODR
+	ttl        time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewCachingODR wraps underlying with a cache that treats entries as fresh
+// for ttl.
+func NewCachingODR(underlying ODR, ttl time.Duration) *CachingODR {
+	return &CachingODR{
+		underlying: underlying,
+		ttl:        ttl,
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+func (c *CachingODR) GetState(ctx context.Context, wallet, token string) (*core.State, *Proof, error) {
+	key := eventKey(wallet, token)
+
+	c.mu.Lock()
+	entry, hasEntry := c.cache[key]
+	c.mu.Unlock()
+
+	if hasEntry && time.Now().Before(entry.expiresAt) {
+		return entry.state, entry.proof, nil
+	}
+
+	state, proof, err := c.underlying.GetState(ctx, wallet, token)
+	if err != nil {
+		if hasEntry {
+			// Soft fail: serve the stale entry rather than surfacing the error.
+			return entry.state, entry.proof, nil
+		}
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	c.cache[key] = cacheEntry{state: state, proof: proof, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return state, proof, nil
+}
@@ -0,0 +1,151 @@
+// Package light lets a node participate in the network without storing
+// every wallet's state, similar in spirit to geth's LES light chain: it
+// tracks manifest headers and challenge-relevant events only, and fetches
+// individual states on demand (with proof) from full nodes.
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// Header is the reduced on-chain view a light node tracks: enough to know
+// it's synced to the right manifest, without following every deposit or
+// state transition.
+type Header struct {
+	Manifest ports.Manifest
+	Height   uint64
+}
+
+// LightAdapter is a reduced BlockchainAdapter for nodes that don't want to
+// track full chain/state history. It exposes the current manifest header
+// and a narrowed event stream: only EventWithdrawalRequested and
+// EventChallenged, the events a light client needs to judge freshness during
+// a challenge period.
+type LightAdapter interface {
+	Header(ctx context.Context) (*Header, error)
+	Subscribe() <-chan ports.BlockchainEvent
+}
+
+// Proof accompanies a core.State returned by ODR.GetState so a light client
+// can convince itself the state is both quorum-authorized and not stale
+// relative to a pending on-chain challenge.
+type Proof struct {
+	// QuorumSigs are the participant quorum's signatures over State.Hash()
+	// (mirrors core.State.Sigs; kept here too so a Proof is self-contained
+	// even if the caller only serializes the proof).
+	QuorumSigs [][]byte
+
+	// FreshnessEvent is the latest on-chain WithdrawalRequested/Challenged
+	// event referencing this wallet+token, if any. Its absence means no
+	// withdrawal is currently pending for the wallet.
+	FreshnessEvent *ports.BlockchainEvent
+}
+
+// ODR (on-demand retrieval) lets a light node fetch a specific wallet+token
+// state, with proof, from full nodes without maintaining its own copy of the
+// full state set.
+type ODR interface {
+	GetState(ctx context.Context, wallet, token string) (*core.State, *Proof, error)
+}
+
+// ErrProofInvalid is returned by VerifyProof when a Proof doesn't actually
+// back up the State it's paired with.
+var ErrProofInvalid = errors.New("light: proof does not verify")
+
+// VerifyProof checks that proof actually backs state up, rather than a light
+// client trusting whatever a single full node handed back: QuorumSigs must
+// include a valid signature (the "sig:<nodeID>:<state hash>" format every
+// NodeHandler signs with, see node.Node.validateAndSign) from at least
+// requiredSigners distinct members of state.Participants, and if
+// FreshnessEvent is set, it must not reference a version of the wallet newer
+// than state.Version, which would mean a challenge or withdrawal already
+// exists against a state this read hasn't caught up to yet.
+func VerifyProof(state *core.State, proof *Proof, requiredSigners int) error {
+	if proof == nil {
+		return fmt.Errorf("%w: no proof accompanying state", ErrProofInvalid)
+	}
+
+	participants := make(map[string]bool, len(state.Participants))
+	for _, p := range state.Participants {
+		participants[p] = true
+	}
+
+	want := state.Hash()
+	signers := make(map[string]bool, len(proof.QuorumSigs))
+	for _, sig := range proof.QuorumSigs {
+		id, hash, ok := parseSig(sig)
+		if !ok || hash != want || !participants[id] {
+			continue
+		}
+		signers[id] = true
+	}
+	if len(signers) < requiredSigners {
+		return fmt.Errorf("%w: only %d of the required %d participant signatures verified", ErrProofInvalid, len(signers), requiredSigners)
+	}
+
+	if proof.FreshnessEvent != nil {
+		if v, ok := freshnessVersion(*proof.FreshnessEvent); ok && v > state.Version {
+			return fmt.Errorf("%w: a challenge/withdrawal references version %d, newer than the served version %d", ErrProofInvalid, v, state.Version)
+		}
+	}
+
+	return nil
+}
+
+// parseSig extracts the signer ID and signed hash out of a "sig:<id>:<hash>"
+// signature, the fake format every NodeHandler in this tree signs with.
+func parseSig(sig []byte) (id, hash string, ok bool) {
+	parts := strings.SplitN(string(sig), ":", 3)
+	if len(parts) != 3 || parts[0] != "sig" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// freshnessVersion extracts the wallet state version a freshness event
+// refers to, if its payload carries one.
+func freshnessVersion(evt ports.BlockchainEvent) (uint64, bool) {
+	switch evt.Type {
+	case ports.EventWithdrawalRequested:
+		if p, ok := evt.Payload.(ports.RequestPayload); ok && p.State != nil {
+			return p.State.Version, true
+		}
+	case ports.EventChallenged:
+		if p, ok := evt.Payload.(ports.ChallengePayload); ok && p.State != nil {
+			return p.State.Version, true
+		}
+	}
+	return 0, false
+}
+
+// eventKey identifies the wallet+token an event's payload refers to, used to
+// index the freshness cache Server keeps.
+func eventKey(wallet, token string) string {
+	return wallet + ":" + token
+}
+
+// referencesWallet extracts the wallet+token an event pertains to, if any.
+func referencesWallet(evt ports.BlockchainEvent) (wallet, token string, ok bool) {
+	switch evt.Type {
+	case ports.EventWithdrawalRequested:
+		p, isType := evt.Payload.(ports.RequestPayload)
+		if !isType || p.State == nil {
+			return "", "", false
+		}
+		return p.State.Wallet, p.State.Token, true
+	case ports.EventChallenged:
+		p, isType := evt.Payload.(ports.ChallengePayload)
+		if !isType || p.State == nil {
+			return "", "", false
+		}
+		return p.State.Wallet, p.State.Token, true
+	default:
+		return "", "", false
+	}
+}
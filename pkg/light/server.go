@@ -0,0 +1,67 @@
+package light
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// Server answers ODR requests on behalf of a full node: it serves states via
+// the node's own P2PAdapter (which already carries the quorum signatures in
+// State.Sigs) and attaches the latest challenge-relevant chain event for the
+// wallet, so a light client can judge freshness.
+type Server struct {
+	chain ports.BlockchainAdapter
+	p2p   ports.P2PAdapter
+
+	mu     sync.RWMutex
+	latest map[string]ports.BlockchainEvent // eventKey(wallet, token) -> latest freshness event
+}
+
+// NewServer creates a Server backed by chain (for freshness events) and p2p
+// (for state lookups).
+func NewServer(chain ports.BlockchainAdapter, p2p ports.P2PAdapter) *Server {
+	return &Server{
+		chain:  chain,
+		p2p:    p2p,
+		latest: make(map[string]ports.BlockchainEvent),
+	}
+}
+
+// Start begins indexing freshness events in the background. It should be
+// called once, alongside the rest of the node's startup.
+func (s *Server) Start() {
+	go s.watch(s.chain.Subscribe())
+}
+
+func (s *Server) watch(events <-chan ports.BlockchainEvent) {
+	for evt := range events {
+		wallet, token, ok := referencesWallet(evt)
+		if !ok {
+			continue
+		}
+		s.mu.Lock()
+		s.latest[eventKey(wallet, token)] = evt
+		s.mu.Unlock()
+	}
+}
+
+// GetState implements ODR for full nodes.
+func (s *Server) GetState(ctx context.Context, wallet, token string) (*core.State, *Proof, error) {
+	state, err := s.p2p.GetLatestState(wallet)
+	if err != nil {
+		return nil, nil, fmt.Errorf("light: fetching state for %s: %w", wallet, err)
+	}
+
+	proof := &Proof{QuorumSigs: state.Sigs}
+	s.mu.RLock()
+	if evt, ok := s.latest[eventKey(wallet, token)]; ok {
+		proof.FreshnessEvent = &evt
+	}
+	s.mu.RUnlock()
+
+	return state, proof, nil
+}
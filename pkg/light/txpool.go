@@ -0,0 +1,93 @@
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// pendingUpdate is a user-signed state update waiting for on-chain
+// confirmation of its base version before it can be submitted.
+type pendingUpdate struct {
+	state  *core.State
+	submit func(*core.State) error
+}
+
+// TxPool queues user-signed state updates for a light client and only
+// submits each one once the ODR layer confirms the wallet's current
+// on-chain version matches what the update was built against. This avoids
+// a light client racing a submission against a version it hasn't actually
+// observed yet. The confirming state is also required to carry a valid
+// Proof (see VerifyProof) before Flush trusts its Version at all, so a
+// single lying full node can't trick a submission into going out early.
+type TxPool struct {
+	odr             ODR
+	requiredSigners int
+
+	mu      sync.Mutex
+	pending []pendingUpdate
+}
+
+// NewTxPool creates a TxPool that confirms freshness via odr, requiring at
+// least requiredSigners valid participant signatures on the state odr
+// returns before trusting its Version.
+func NewTxPool(odr ODR, requiredSigners int) *TxPool {
+	return &TxPool{odr: odr, requiredSigners: requiredSigners}
+}
+
+// Queue adds a state update to the pool. submit is called once the update's
+// base version (state.Version - 1) is confirmed as the current on-chain
+// version for the wallet.
+func (tp *TxPool) Queue(state *core.State, submit func(*core.State) error) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.pending = append(tp.pending, pendingUpdate{state: state, submit: submit})
+}
+
+// Flush attempts to confirm and submit every queued update, in FIFO order.
+// Updates whose base version isn't confirmed yet, or whose submit call
+// fails, are left in the pool for a future Flush call; Flush always
+// processes every queued update rather than stopping at the first failure,
+// returning every submit error it hit joined together.
+func (tp *TxPool) Flush(ctx context.Context) error {
+	tp.mu.Lock()
+	remaining := tp.pending
+	tp.pending = nil
+	tp.mu.Unlock()
+
+	var still []pendingUpdate
+	var errs []error
+	for _, u := range remaining {
+		confirmed, proof, err := tp.odr.GetState(ctx, u.state.Wallet, u.state.Token)
+		if err != nil {
+			still = append(still, u)
+			continue
+		}
+		if err := VerifyProof(confirmed, proof, tp.requiredSigners); err != nil {
+			still = append(still, u)
+			errs = append(errs, fmt.Errorf("light: confirming state for %s: %w", u.state.Wallet, err))
+			continue
+		}
+
+		wantBase := u.state.Version - 1
+		if confirmed.Version != wantBase {
+			// Not confirmed yet (or already superseded); keep waiting.
+			still = append(still, u)
+			continue
+		}
+
+		if err := u.submit(u.state); err != nil {
+			still = append(still, u)
+			errs = append(errs, fmt.Errorf("light: submitting state for %s: %w", u.state.Wallet, err))
+			continue
+		}
+	}
+
+	tp.mu.Lock()
+	tp.pending = append(still, tp.pending...)
+	tp.mu.Unlock()
+	return errors.Join(errs...)
+}
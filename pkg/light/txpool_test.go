@@ -0,0 +1,163 @@
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// fakeODR reports a fixed confirmed version for every wallet/token pair,
+// signed by every id in participants unless unsigned is set (to simulate a
+// server that can't or won't back its answer with quorum signatures).
+type fakeODR struct {
+	confirmed    map[string]uint64
+	participants []string
+	unsigned     bool
+}
+
+func (f *fakeODR) GetState(ctx context.Context, wallet, token string) (*core.State, *Proof, error) {
+	state := &core.State{Wallet: wallet, Token: token, Version: f.confirmed[eventKey(wallet, token)], Participants: f.participants}
+	proof := &Proof{}
+	if !f.unsigned {
+		for _, id := range f.participants {
+			proof.QuorumSigs = append(proof.QuorumSigs, []byte(fmt.Sprintf("sig:%s:%s", id, state.Hash())))
+		}
+	}
+	return state, proof, nil
+}
+
+func TestTxPool_FlushKeepsProcessingAfterASubmitError(t *testing.T) {
+	odr := &fakeODR{
+		participants: []string{"node-0"},
+		confirmed: map[string]uint64{
+			eventKey("wallet-a", "usdt"): 1, // update a's base version (1) is confirmed
+			eventKey("wallet-b", "usdt"): 1, // update b's base version (1) is confirmed too
+		},
+	}
+	tp := NewTxPool(odr, 1)
+
+	var submittedB bool
+	tp.Queue(&core.State{Wallet: "wallet-a", Token: "usdt", Version: 2}, func(*core.State) error {
+		return errors.New("boom")
+	})
+	tp.Queue(&core.State{Wallet: "wallet-b", Token: "usdt", Version: 2}, func(*core.State) error {
+		submittedB = true
+		return nil
+	})
+
+	err := tp.Flush(context.Background())
+	if err == nil {
+		t.Fatal("expected Flush to report the failing submit's error")
+	}
+	if !submittedB {
+		t.Fatal("expected wallet-b's update to still be submitted despite wallet-a's failure")
+	}
+
+	tp.mu.Lock()
+	pending := tp.pending
+	tp.mu.Unlock()
+	if len(pending) != 1 || pending[0].state.Wallet != "wallet-a" {
+		t.Fatalf("expected only wallet-a's failed update to remain queued, got %+v", pending)
+	}
+}
+
+func TestTxPool_FlushRetriesUnconfirmedUpdates(t *testing.T) {
+	odr := &fakeODR{
+		participants: []string{"node-0"},
+		confirmed:    map[string]uint64{eventKey("wallet-a", "usdt"): 0}, // base version (1) not confirmed yet
+	}
+	tp := NewTxPool(odr, 1)
+
+	submitted := false
+	tp.Queue(&core.State{Wallet: "wallet-a", Token: "usdt", Version: 2}, func(*core.State) error {
+		submitted = true
+		return nil
+	})
+
+	if err := tp.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if submitted {
+		t.Fatal("expected the update to be held back until its base version is confirmed")
+	}
+
+	odr.confirmed[eventKey("wallet-a", "usdt")] = 1
+	if err := tp.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if !submitted {
+		t.Fatal("expected the update to submit once its base version was confirmed")
+	}
+}
+
+func TestTxPool_FlushRefusesToActOnAnUnverifiedProof(t *testing.T) {
+	odr := &fakeODR{
+		participants: []string{"node-0"},
+		confirmed:    map[string]uint64{eventKey("wallet-a", "usdt"): 1},
+		unsigned:     true, // the full node doesn't back its answer with any signatures
+	}
+	tp := NewTxPool(odr, 1)
+
+	submitted := false
+	tp.Queue(&core.State{Wallet: "wallet-a", Token: "usdt", Version: 2}, func(*core.State) error {
+		submitted = true
+		return nil
+	})
+
+	if err := tp.Flush(context.Background()); err == nil {
+		t.Fatal("expected Flush to report the unverified proof as an error")
+	}
+	if submitted {
+		t.Fatal("expected Flush to refuse to submit against an unverified confirmation")
+	}
+
+	tp.mu.Lock()
+	pending := tp.pending
+	tp.mu.Unlock()
+	if len(pending) != 1 {
+		t.Fatalf("expected the update to remain queued for retry, got %d pending", len(pending))
+	}
+}
+
+func TestVerifyProof_RequiresEnoughDistinctParticipantSignatures(t *testing.T) {
+	state := &core.State{Wallet: "wallet-a", Token: "usdt", Version: 2, Participants: []string{"node-0", "node-1", "node-2"}}
+	hash := state.Hash()
+
+	proof := &Proof{QuorumSigs: [][]byte{
+		[]byte(fmt.Sprintf("sig:node-0:%s", hash)),
+		[]byte(fmt.Sprintf("sig:node-0:%s", hash)), // duplicate signer, shouldn't count twice
+		[]byte(fmt.Sprintf("sig:outsider:%s", hash)), // not a participant
+	}}
+
+	if err := VerifyProof(state, proof, 2); err == nil {
+		t.Fatal("expected VerifyProof to reject a proof with only one genuine distinct participant signature")
+	}
+
+	proof.QuorumSigs = append(proof.QuorumSigs, []byte(fmt.Sprintf("sig:node-1:%s", hash)))
+	if err := VerifyProof(state, proof, 2); err != nil {
+		t.Fatalf("VerifyProof: %v", err)
+	}
+}
+
+func TestVerifyProof_RejectsSignatureOverAnotherState(t *testing.T) {
+	state := &core.State{Wallet: "wallet-a", Token: "usdt", Version: 2, Participants: []string{"node-0"}}
+	proof := &Proof{QuorumSigs: [][]byte{[]byte("sig:node-0:some-other-hash")}}
+
+	if err := VerifyProof(state, proof, 1); err == nil {
+		t.Fatal("expected VerifyProof to reject a signature over a different state hash")
+	}
+}
+
+func TestVerifyProof_RejectsStaleFreshnessEvent(t *testing.T) {
+	state := &core.State{Wallet: "wallet-a", Token: "usdt", Version: 2, Participants: []string{"node-0"}}
+	proof := &Proof{
+		QuorumSigs:     [][]byte{[]byte(fmt.Sprintf("sig:node-0:%s", state.Hash()))},
+		FreshnessEvent: nil,
+	}
+	if err := VerifyProof(state, proof, 1); err != nil {
+		t.Fatalf("VerifyProof with no freshness event: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package light
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// VerifyingODR wraps an ODR and rejects any result whose Proof doesn't
+// verify against the State it's paired with (see VerifyProof), rather than
+// passing it straight through the way CachingODR does.
+type VerifyingODR struct {
+	underlying      ODR
+	requiredSigners int
+}
+
+// NewVerifyingODR wraps underlying, requiring at least requiredSigners valid
+// participant signatures on every state it returns.
+func NewVerifyingODR(underlying ODR, requiredSigners int) *VerifyingODR {
+	return &VerifyingODR{underlying: underlying, requiredSigners: requiredSigners}
+}
+
+func (v *VerifyingODR) GetState(ctx context.Context, wallet, token string) (*core.State, *Proof, error) {
+	state, proof, err := v.underlying.GetState(ctx, wallet, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := VerifyProof(state, proof, v.requiredSigners); err != nil {
+		return nil, nil, fmt.Errorf("light: verifying state for %s: %w", wallet, err)
+	}
+	return state, proof, nil
+}
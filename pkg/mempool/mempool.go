@@ -0,0 +1,342 @@
+// Package mempool buffers incoming core.State candidates before a node
+// commits them, instead of node.Node writing straight into its store the
+// moment OnNewState/OnSignRequest fires. Modeled loosely on a blockchain
+// transaction mempool: entries are indexed by wallet, a per-wallet
+// expected-version rule (à la Filecoin's expected-nonce check) bounds how
+// far ahead of the node's current state an entry may get before it's
+// dropped, and a fee/size/age score decides which of several competing
+// candidates for the same wallet wins when the node's worker loop drains
+// the pool.
+package mempool
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+var (
+	ErrNoState     = errors.New("mempool: entry has no state")
+	ErrStale       = errors.New("mempool: version is not ahead of the current baseline")
+	ErrTooFarAhead = errors.New("mempool: version is too far ahead of the current baseline")
+	ErrPoolFull    = errors.New("mempool: pool is full and no lower-scoring entry to evict")
+)
+
+// DropReason labels why Add rejected an entry, for metrics.
+type DropReason string
+
+const (
+	DropDuplicate  DropReason = "duplicate"
+	DropStale      DropReason = "stale"
+	DropVersionGap DropReason = "version_gap"
+	DropCapacity   DropReason = "capacity"
+)
+
+// penaltyFactor is applied to an entry's score when it looks like it would
+// fail the same conservation/version check node.Node.OnSignRequest makes
+// (see "invalid" below), so it sorts behind every entry that wouldn't.
+const penaltyFactor = 0.01
+
+// Entry is one candidate state update buffered for a wallet.
+type Entry struct {
+	State       *core.State
+	ArrivalTime time.Time
+	Fee         *big.Int
+	Sender      string // NodeID or peer that delivered this entry, for diagnostics
+}
+
+// BaselineFunc returns the state a node currently considers authoritative
+// for wallet, or nil if it has none yet. Pool calls back into it rather than
+// tracking its own copy, so it's always consulting the node's live store.
+type BaselineFunc func(wallet string) *core.State
+
+// Pool is a bounded, per-wallet-indexed buffer of Entries awaiting a node's
+// worker loop to Pop and commit the best-scoring one.
+type Pool struct {
+	capacity      int
+	maxVersionGap uint64
+	baseline      BaselineFunc
+	metrics       ports.Metrics
+
+	mu       sync.Mutex
+	entries  map[string]*Entry          // State.Hash() -> entry
+	byWallet map[string]map[string]bool // wallet -> set of hashes
+
+	notify chan string // wallet names with new/changed entries; buffered, best-effort
+}
+
+// New creates a Pool. capacity <= 0 is treated as 1000. maxVersionGap bounds
+// how far ahead of baseline(wallet).Version an entry's Version may be before
+// Add drops it; 0 means unbounded. metrics may be nil.
+func New(capacity int, maxVersionGap uint64, baseline BaselineFunc, metrics ports.Metrics) *Pool {
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &Pool{
+		capacity:      capacity,
+		maxVersionGap: maxVersionGap,
+		baseline:      baseline,
+		metrics:       metrics,
+		entries:       make(map[string]*Entry),
+		byWallet:      make(map[string]map[string]bool),
+		notify:        make(chan string, 256),
+	}
+}
+
+// Notify returns the channel a worker loop should range over: each value is
+// a wallet with a new or changed entry worth re-evaluating via Pop. Sends
+// are non-blocking and best-effort, so a burst of Adds for the same wallet
+// may only wake the worker once; that's fine since Pop always returns
+// whatever currently scores best, not "the" entry that was signaled.
+func (p *Pool) Notify() <-chan string {
+	return p.notify
+}
+
+// Add buffers e, after checking it isn't a duplicate of an already-pooled
+// entry (same State.Hash()) and isn't stale or too far ahead of baseline's
+// current version. If the pool is full, e only displaces the
+// lowest-scoring entry currently pooled, and only if e would outscore it.
+func (p *Pool) Add(e *Entry) error {
+	if e.State == nil {
+		return ErrNoState
+	}
+	if e.Fee == nil {
+		e.Fee = big.NewInt(0)
+	}
+	if e.ArrivalTime.IsZero() {
+		e.ArrivalTime = time.Now()
+	}
+
+	hash := e.State.Hash()
+	base := p.baseline(e.State.Wallet)
+
+	p.mu.Lock()
+	if _, exists := p.entries[hash]; exists {
+		p.mu.Unlock()
+		p.metrics.IncCounter("mempool_dropped", map[string]string{"reason": string(DropDuplicate)})
+		return nil
+	}
+
+	if base != nil {
+		if e.State.Version <= base.Version {
+			p.mu.Unlock()
+			p.metrics.IncCounter("mempool_dropped", map[string]string{"reason": string(DropStale)})
+			return ErrStale
+		}
+		if p.maxVersionGap > 0 && e.State.Version-base.Version > p.maxVersionGap {
+			p.mu.Unlock()
+			p.metrics.IncCounter("mempool_dropped", map[string]string{"reason": string(DropVersionGap)})
+			return ErrTooFarAhead
+		}
+	}
+
+	if len(p.entries) >= p.capacity {
+		if !p.evictForLocked(e) {
+			p.mu.Unlock()
+			p.metrics.IncCounter("mempool_dropped", map[string]string{"reason": string(DropCapacity)})
+			return ErrPoolFull
+		}
+	}
+
+	p.entries[hash] = e
+	if p.byWallet[e.State.Wallet] == nil {
+		p.byWallet[e.State.Wallet] = make(map[string]bool)
+	}
+	p.byWallet[e.State.Wallet][hash] = true
+	p.mu.Unlock()
+
+	p.metrics.IncCounter("mempool_added", nil)
+	select {
+	case p.notify <- e.State.Wallet:
+	default:
+	}
+	return nil
+}
+
+// Pop removes and returns the best-scoring entry pooled for wallet, or
+// (nil, false) if none is pooled.
+func (p *Pool) Pop(wallet string) (*Entry, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hashes := p.byWallet[wallet]
+	if len(hashes) == 0 {
+		return nil, false
+	}
+
+	base := p.baseline(wallet)
+	now := time.Now()
+
+	var bestHash string
+	var best *Entry
+	var bestScore float64
+	for hash := range hashes {
+		e := p.entries[hash]
+		s := p.score(e, base, now)
+		if best == nil || s > bestScore {
+			bestHash, best, bestScore = hash, e, s
+		}
+	}
+
+	p.removeLocked(bestHash)
+	return best, true
+}
+
+// Remove evicts the entry with the given content hash, if any is pooled.
+func (p *Pool) Remove(hash string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removeLocked(hash)
+}
+
+func (p *Pool) removeLocked(hash string) {
+	e, ok := p.entries[hash]
+	if !ok {
+		return
+	}
+	delete(p.entries, hash)
+	if set := p.byWallet[e.State.Wallet]; set != nil {
+		delete(set, hash)
+		if len(set) == 0 {
+			delete(p.byWallet, e.State.Wallet)
+		}
+	}
+}
+
+// Iterate returns a snapshot of every entry currently pooled for wallet,
+// without removing them.
+func (p *Pool) Iterate(wallet string) []*Entry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]*Entry, 0, len(p.byWallet[wallet]))
+	for hash := range p.byWallet[wallet] {
+		out = append(out, p.entries[hash])
+	}
+	return out
+}
+
+func (p *Pool) evictForLocked(candidate *Entry) bool {
+	now := time.Now()
+	candidateScore := p.score(candidate, p.baseline(candidate.State.Wallet), now)
+
+	var lowestHash string
+	var lowestScore float64
+	found := false
+	for hash, e := range p.entries {
+		s := p.score(e, p.baseline(e.State.Wallet), now)
+		if !found || s < lowestScore {
+			lowestHash, lowestScore, found = hash, s, true
+		}
+	}
+	if !found || lowestScore >= candidateScore {
+		return false
+	}
+
+	p.removeLocked(lowestHash)
+	p.metrics.IncCounter("mempool_evicted", nil)
+	return true
+}
+
+// score is fee/size*ageDecay, reduced by penaltyFactor if the entry looks
+// like it would fail the same check node.Node.OnSignRequest makes before
+// signing (a stale/duplicate version, or an invalid transition relative to
+// baseline), so such entries sort to the back rather than being rejected
+// outright here (Add's gap check already caught the clearly unroutable
+// ones; this only affects selection order among what's left).
+func (p *Pool) score(e *Entry, base *core.State, now time.Time) float64 {
+	feeF, _ := new(big.Float).SetInt(e.Fee).Float64()
+	size := float64(estimateSize(e.State))
+	if size <= 0 {
+		size = 1
+	}
+	age := now.Sub(e.ArrivalTime).Seconds()
+	if age < 0 {
+		age = 0
+	}
+	ageDecay := 1 / (1 + age)
+
+	s := feeF / size * ageDecay
+	if invalid(e.State, base) {
+		s *= penaltyFactor
+	}
+	return s
+}
+
+// invalid reports whether state looks like it would fail node.Node's
+// conservation check: see conservationViolated below.
+func invalid(state *core.State, base *core.State) bool {
+	if base == nil {
+		return false
+	}
+	if state.Version <= base.Version {
+		return true
+	}
+	return conservationViolated(base, state)
+}
+
+// conservationViolated mirrors node.Node's validateTransition: Balance may
+// only move by the sum of HTLCs actually settled between base and state
+// (hash-matched via core.HashPreimage(state.Preimage)). AddHTLC and
+// TimeoutHTLC never move Balance, only a matching SettleHTLC does, so
+// treating "Balance plus everything reserved in Pending" as a constant
+// (the naive check this replaced) flags every legitimate settlement as
+// invalid, since the settled amount has genuinely left custody rather than
+// just changed buckets.
+func conservationViolated(base, state *core.State) bool {
+	before := make(map[string]core.HTLC, len(base.Pending))
+	for _, h := range base.Pending {
+		before[h.Hash] = h
+	}
+	after := make(map[string]bool, len(state.Pending))
+	for _, h := range state.Pending {
+		after[h.Hash] = true
+	}
+
+	settled := new(big.Int)
+	for hash, h := range before {
+		if after[hash] {
+			continue
+		}
+		switch {
+		case state.Preimage != "" && core.HashPreimage(state.Preimage) == hash:
+			settled.Add(settled, h.Amount)
+		case h.Expiry <= state.Version:
+			// Timed out: removed from Pending without moving Balance.
+		default:
+			return true
+		}
+	}
+
+	wantBalance := new(big.Int).Sub(base.Balance, settled)
+	return state.Balance.Cmp(wantBalance) != 0
+}
+
+// estimateSize is a rough proxy for wire size: exact encoding doesn't matter
+// for scoring purposes, only that it scales with how much of the State
+// there is to propagate/verify.
+func estimateSize(s *core.State) int {
+	size := len(s.Wallet) + len(s.Token) + 8 + len(s.Balance.Bytes())
+	for _, p := range s.Participants {
+		size += len(p)
+	}
+	for _, sig := range s.Sigs {
+		size += len(sig)
+	}
+	for _, h := range s.Pending {
+		size += len(h.Hash) + len(h.Amount.Bytes()) + 8 + len(h.Payer) + len(h.Payee)
+	}
+	return size
+}
+
+// noopMetrics discards every counter; the default when metrics is nil.
+type noopMetrics struct{}
+
+func (noopMetrics) IncCounter(name string, labels map[string]string) {}
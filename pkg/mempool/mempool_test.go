@@ -0,0 +1,87 @@
+package mempool
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+func mkState(version uint64, balance int64, pending ...core.HTLC) *core.State {
+	return &core.State{Wallet: "wallet-a", Token: "token-usdt", Version: version, Balance: big.NewInt(balance), Pending: pending}
+}
+
+func TestScore_DoesNotPenalizeLegitimateSettlement(t *testing.T) {
+	preimage := "shared-secret"
+	hash := core.HashPreimage(preimage)
+
+	base := mkState(1, 100, core.HTLC{Hash: hash, Amount: big.NewInt(30), Expiry: 10, Payer: "wallet-a", Payee: "wallet-b"})
+	settled := mkState(2, 70)
+	settled.Preimage = preimage
+
+	e := &Entry{State: settled, Fee: big.NewInt(10), ArrivalTime: time.Now()}
+	// fabricated doesn't account for the settled HTLC at all (keeps the
+	// full 100 balance with the HTLC simply vanishing): a true violation,
+	// included here so the settled entry's score is visibly NOT penalized
+	// like this one is.
+	fabricated := &Entry{State: mkState(2, 130), Fee: big.NewInt(10), ArrivalTime: time.Now()}
+
+	now := time.Now()
+	p := &Pool{}
+	scoreSettled := p.score(e, base, now)
+	scoreFabricated := p.score(fabricated, base, now)
+	if scoreSettled <= scoreFabricated*0.5 {
+		t.Fatalf("legitimate settlement scored %v, expected it well above the penalized fabricated entry's score %v", scoreSettled, scoreFabricated)
+	}
+}
+
+func TestScore_PenalizesTrueConservationViolation(t *testing.T) {
+	base := mkState(1, 100)
+	bogus := mkState(2, 200) // fabricates balance out of nowhere
+
+	p := &Pool{}
+	now := time.Now()
+	s := p.score(&Entry{State: bogus, Fee: big.NewInt(10), ArrivalTime: now}, base, now)
+	clean := p.score(&Entry{State: mkState(2, 100), Fee: big.NewInt(10), ArrivalTime: now}, base, now)
+	if s >= clean {
+		t.Fatalf("fabricated balance scored %v, expected it penalized below a clean entry's score %v", s, clean)
+	}
+}
+
+func TestScore_AcceptsTimeoutWithoutPenalty(t *testing.T) {
+	base := mkState(1, 100, core.HTLC{Hash: "h1", Amount: big.NewInt(30), Expiry: 2, Payer: "wallet-a", Payee: "wallet-b"})
+	timedOut := mkState(2, 100) // Balance unchanged, HTLC just dropped past its expiry
+
+	p := &Pool{}
+	now := time.Now()
+	s := p.score(&Entry{State: timedOut, Fee: big.NewInt(10), ArrivalTime: now}, base, now)
+	clean := p.score(&Entry{State: mkState(2, 100), Fee: big.NewInt(10), ArrivalTime: now}, base, now)
+	if s != clean {
+		t.Fatalf("timed-out-HTLC entry scored %v, want same as a baseline-consistent entry %v (no penalty)", s, clean)
+	}
+}
+
+func TestPool_EvictForLockedPrefersValidOverFabricated(t *testing.T) {
+	base := mkState(1, 100)
+	baseline := func(wallet string) *core.State { return base }
+	p := New(1, 0, baseline, nil)
+
+	fabricated := &Entry{State: mkState(2, 999), Fee: big.NewInt(1)}
+	if err := p.Add(fabricated); err != nil {
+		t.Fatalf("Add(fabricated): %v", err)
+	}
+
+	legit := &Entry{State: mkState(2, 100), Fee: big.NewInt(1)}
+	if err := p.Add(legit); err != nil {
+		t.Fatalf("Add(legit) should have evicted the fabricated entry: %v", err)
+	}
+
+	got, ok := p.Pop("wallet-a")
+	if !ok {
+		t.Fatal("expected an entry pooled for wallet-a")
+	}
+	if got.State.Balance.Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("expected the legitimate entry to win eviction, got balance %s", got.State.Balance)
+	}
+}
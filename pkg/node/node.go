@@ -6,11 +6,24 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/mempool"
 	"github.com/mod/clearnet/pkg/ports"
+	"github.com/mod/clearnet/pkg/routing"
 )
 
+// maxPendingVersionGap bounds how far ahead of a wallet's currently stored
+// version an incoming state may get before the mempool drops it outright,
+// the same expected-nonce-style guard Filecoin applies to its mempool.
+const maxPendingVersionGap = 5
+
+// htlcReclaimInterval is how often reclaimLoop scans this node's store for
+// Pending HTLCs whose Expiry has passed so the payer can get its reservation
+// back; it doesn't need to be prompt, just eventually run.
+const htlcReclaimInterval = 5 * time.Second
+
 type Node struct {
 	ID    string
 	store map[string]*core.State // Wallet -> State
@@ -19,6 +32,9 @@ type Node struct {
 	chain    ports.BlockchainAdapter
 	p2p      ports.P2PAdapter
 	registry ports.Registry
+	router   *routing.Graph
+	pool     *mempool.Pool // OnNewState candidates; drainMempool commits from this one
+	signPool *mempool.Pool // OnSignRequest candidates; never drained/committed, see OnSignRequest
 }
 
 func NewNode(id string, chain ports.BlockchainAdapter, p2p ports.P2PAdapter, registry ports.Registry) *Node {
@@ -28,10 +44,21 @@ func NewNode(id string, chain ports.BlockchainAdapter, p2p ports.P2PAdapter, reg
 		chain:    chain,
 		p2p:      p2p,
 		registry: registry,
+		router:   routing.NewGraph(id, p2p),
 	}
+	n.pool = mempool.New(1000, maxPendingVersionGap, n.baselineFor, nil)
+	n.signPool = mempool.New(1000, maxPendingVersionGap, n.baselineFor, nil)
 	return n
 }
 
+// baselineFor is the mempool.BaselineFunc this node's pool uses to score and
+// gap-check pending entries against what's actually committed.
+func (n *Node) baselineFor(wallet string) *core.State {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.store[wallet]
+}
+
 func (n *Node) Start() {
 	// Register with P2P
 	n.p2p.RegisterNode(n.ID, n)
@@ -40,6 +67,14 @@ func (n *Node) Start() {
 	events := n.chain.Subscribe()
 	go n.handleBlockchainEvents(events)
 
+	// Drain the mempool: OnNewState only buffers candidates, this loop picks
+	// the best-scoring one per wallet and actually commits it.
+	go n.drainMempool()
+
+	// Reclaim any Pending HTLC this node's own wallets are owed back once its
+	// Expiry passes.
+	go n.reclaimLoop()
+
 	// Register with Node Registry
 	// Generate a mock [32]byte ID from string ID
 	var nodeID [32]byte
@@ -48,7 +83,7 @@ func (n *Node) Start() {
 
 	// Mock stake
 	stake := big.NewInt(250000)
-	
+
 	err := n.registry.Register(context.Background(), nodeID, "localhost", 9000, stake)
 	if err != nil {
 		fmt.Printf("[Node %s] Failed to register: %v\n", n.ID, err)
@@ -108,7 +143,10 @@ func (n *Node) handleWithdrawalRequest(p ports.RequestPayload) {
 	if localState.Version > reqState.Version {
 		// FRAUD DETECTED!
 		fmt.Printf("[Node %s] 🚨 FRAUD DETECTED! Challenging...\n", n.ID)
-		err := n.chain.Challenge(localState, n.ID)
+		// A node challenging fraud against its own stored state isn't
+		// acting as a paid watchtower, so it requests no bounty (see
+		// pkg/watchtower, which does).
+		err := n.chain.Challenge(localState, n.ID, big.NewInt(0))
 		if err != nil {
 			fmt.Printf("[Node %s] Challenge failed: %v\n", n.ID, err)
 		}
@@ -117,26 +155,192 @@ func (n *Node) handleWithdrawalRequest(p ports.RequestPayload) {
 
 // --- NodeHandler Interface ---
 
+// OnNewState no longer writes n.store directly: it only buffers state as a
+// mempool candidate for its wallet. drainMempool picks the best-scoring
+// candidate per wallet and commits it, so competing updates arriving at
+// once are resolved by score rather than by whichever goroutine's write
+// happens to land last.
 func (n *Node) OnNewState(state *core.State) {
-	n.mu.Lock()
-	defer n.mu.Unlock()
+	entry := &mempool.Entry{State: state, ArrivalTime: time.Now(), Fee: big.NewInt(0)}
+	if err := n.pool.Add(entry); err != nil {
+		fmt.Printf("[Node %s] Dropped incoming state for %s: %v\n", n.ID, state.Wallet, err)
+	}
+}
+
+func (n *Node) OnGossip(msg ports.GossipMessage) {
+	n.router.HandleGossip(msg)
+}
+
+// drainMempool commits the best-scoring pending entry for each wallet n.pool
+// signals as changed. It's the asynchronous counterpart of the old
+// synchronous OnNewState write. It only ever drains n.pool, never signPool:
+// a sign-request candidate must not reach n.store until OnSignRequest has
+// actually validated it (and, in a real deployment, until quorum signatures
+// exist), so it has its own pool that nothing commits from.
+func (n *Node) drainMempool() {
+	for wallet := range n.pool.Notify() {
+		entry, ok := n.pool.Pop(wallet)
+		if !ok {
+			continue
+		}
+		n.commitState(entry.State)
+	}
+}
 
+func (n *Node) commitState(state *core.State) {
+	n.mu.Lock()
 	current, exists := n.store[state.Wallet]
 	if exists && current.Version >= state.Version {
-		// Old or same state, ignore
+		// Superseded by the time we got around to it; drop silently.
+		n.mu.Unlock()
 		return
 	}
-
-	// In reality: Verify signatures of Quorum.
-	// We assume if it reached us via P2P and looks valid, we store it.
 	n.store[state.Wallet] = state
+	n.mu.Unlock()
+
 	fmt.Printf("[Node %s] Updated local state for %s to Ver: %d Balance: %s\n", n.ID, state.Wallet, state.Version, state.Balance)
+
+	if err := n.router.Advertise(state.Wallet, state.Token, state.AvailableBalance()); err != nil {
+		fmt.Printf("[Node %s] Failed to advertise liquidity for %s: %v\n", n.ID, state.Wallet, err)
+	}
+}
+
+// reclaimLoop periodically reclaims this node's own Pending HTLCs that have
+// timed out. It's the analogue of drainMempool for the one state transition
+// nobody else has a reason to initiate on a wallet's behalf: a payer waiting
+// on an unresponsive payee has to reclaim its own funds.
+func (n *Node) reclaimLoop() {
+	ticker := time.NewTicker(htlcReclaimInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		n.reclaimExpiredHTLCs()
+	}
+}
+
+func (n *Node) reclaimExpiredHTLCs() {
+	n.mu.RLock()
+	candidates := make([]*core.State, 0, len(n.store))
+	for _, s := range n.store {
+		candidates = append(candidates, s)
+	}
+	n.mu.RUnlock()
+
+	for _, s := range candidates {
+		for _, h := range s.Pending {
+			if h.Expiry > s.Version {
+				continue // not yet eligible
+			}
+			next, err := s.TimeoutHTLC(h.Hash)
+			if err != nil {
+				continue // already reclaimed by a concurrent pass
+			}
+			if err := n.signQuorum(next); err != nil {
+				fmt.Printf("[Node %s] Failed to reclaim timed-out HTLC %s for %s: %v\n", n.ID, h.Hash, s.Wallet, err)
+				continue
+			}
+			if err := n.p2p.PublishState(next); err != nil {
+				fmt.Printf("[Node %s] Failed to publish reclaimed state for %s: %v\n", n.ID, s.Wallet, err)
+			}
+		}
+	}
 }
 
+// signQuorum collects a signature from every participant in state (round-
+// robin RequestSignature, the same pattern cmd/simdemo's signQuorum and
+// routing.Graph.signAndPublish use) and appends them to state.Sigs.
+func (n *Node) signQuorum(state *core.State) error {
+	for _, nodeID := range state.Participants {
+		sig, err := n.p2p.RequestSignature(nodeID, state)
+		if err != nil {
+			return fmt.Errorf("request signature from %s: %w", nodeID, err)
+		}
+		state.Sigs = append(state.Sigs, sig)
+	}
+	return nil
+}
+
+// OnSignRequest is a synchronous RPC (unlike OnNewState's async mempool
+// drain, RequestSignature callers block on a reply), so it still validates
+// and signs inline. It buffers state into signPool first purely to reuse
+// Pool.Add's stale/version-gap checks and drop-reason metrics, then removes
+// it immediately rather than leaving it for a drain loop to pick up later.
+// signPool is a separate pool from n.pool (which drainMempool does commit
+// from): nothing ever Pops signPool, so a candidate can't land in n.store
+// via that path before this method's own validation below has run.
 func (n *Node) OnSignRequest(state *core.State) ([]byte, error) {
-	// In reality: Validate transition (Balance check, etc)
-	// For this mock: We just sign it.
+	entry := &mempool.Entry{State: state, ArrivalTime: time.Now(), Fee: big.NewInt(0)}
+	if err := n.signPool.Add(entry); err != nil {
+		return nil, fmt.Errorf("node: refusing to sign %s: %w", state.Wallet, err)
+	}
+	defer n.signPool.Remove(state.Hash())
+
+	return n.validateAndSign(state)
+}
+
+// validateAndSign is OnSignRequest's validation-and-signing logic, split out
+// of the signPool bookkeeping around it so routing's multi-hop HTLC driver
+// and any other in-process caller that already holds a validated state can
+// reuse the same checks without re-entering signPool.
+func (n *Node) validateAndSign(state *core.State) ([]byte, error) {
+	n.mu.RLock()
+	current, exists := n.store[state.Wallet]
+	n.mu.RUnlock()
+
+	if exists {
+		if err := validateTransition(current, state); err != nil {
+			return nil, fmt.Errorf("node: refusing to sign %s: %w", state.Wallet, err)
+		}
+	}
 
 	sig := []byte(fmt.Sprintf("sig:%s:%s", n.ID, state.Hash()))
 	return sig, nil
 }
+
+// validateTransition checks that state is a legitimate transition from
+// current, the same invariants that must hold across any transition
+// (AddHTLC/SettleHTLC/TimeoutHTLC, or a plain transfer):
+//
+//   - expiry ordering: an HTLC newly added to Pending can't already be
+//     timed-out at the version that adds it (Payer could otherwise reclaim
+//     it the instant it's created).
+//   - preimage matching: an HTLC that disappeared from Pending must be
+//     accounted for by either a matching State.Preimage (SettleHTLC) or an
+//     Expiry that has actually elapsed (TimeoutHTLC); a quorum member
+//     should never sign away a pending HTLC on say-so alone.
+//   - settlement accounting: Balance may only move by the sum of HTLCs this
+//     transition legitimately settles (AddHTLC and TimeoutHTLC never touch
+//     Balance, only Pending — see their doc comments in pkg/core).
+func validateTransition(current, state *core.State) error {
+	before := make(map[string]core.HTLC, len(current.Pending))
+	for _, h := range current.Pending {
+		before[h.Hash] = h
+	}
+	after := make(map[string]bool, len(state.Pending))
+	for _, h := range state.Pending {
+		after[h.Hash] = true
+		if _, existed := before[h.Hash]; !existed && h.Expiry <= state.Version {
+			return fmt.Errorf("HTLC %s has already-elapsed expiry %d at version %d", h.Hash, h.Expiry, state.Version)
+		}
+	}
+
+	settled := new(big.Int)
+	for hash, h := range before {
+		if after[hash] {
+			continue // still pending, untouched by this transition
+		}
+		switch {
+		case state.Preimage != "" && core.HashPreimage(state.Preimage) == hash:
+			settled.Add(settled, h.Amount) // legitimately settled
+		case h.Expiry <= state.Version:
+			// legitimately timed out; Balance doesn't move for this one
+		default:
+			return fmt.Errorf("HTLC %s removed without a matching preimage or an elapsed expiry", hash)
+		}
+	}
+
+	wantBalance := new(big.Int).Sub(current.Balance, settled)
+	if state.Balance.Cmp(wantBalance) != 0 {
+		return fmt.Errorf("balance moved from %s to %s, expected %s after accounting for settled HTLCs", current.Balance, state.Balance, wantBalance)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+func mkState(balance int64, pending []core.HTLC) *core.State {
+	return &core.State{
+		Wallet:  "wallet-1",
+		Token:   "token-usdt",
+		Version: 2,
+		Balance: big.NewInt(balance),
+		Pending: pending,
+	}
+}
+
+func TestValidateTransition_RejectsConservationViolation(t *testing.T) {
+	current := mkState(100, nil)
+	next := mkState(101, nil) // balance grew out of nowhere
+	if err := validateTransition(current, next); err == nil {
+		t.Fatal("expected an error for a total that changed without a deposit")
+	}
+}
+
+func TestValidateTransition_RejectsAlreadyElapsedExpiry(t *testing.T) {
+	current := mkState(100, nil)
+	next := mkState(100, []core.HTLC{{
+		Hash: "h1", Amount: big.NewInt(10), Expiry: 1, Payer: "wallet-1", Payee: "wallet-2",
+	}})
+	next.Version = 2 // Expiry (1) already <= Version (2)
+	if err := validateTransition(current, next); err == nil {
+		t.Fatal("expected an error for an HTLC created already past its own expiry")
+	}
+}
+
+func TestValidateTransition_RejectsRemovalWithoutPreimageOrTimeout(t *testing.T) {
+	htlc := core.HTLC{Hash: "h1", Amount: big.NewInt(10), Expiry: 99, Payer: "wallet-1", Payee: "wallet-2"}
+	current := mkState(90, []core.HTLC{htlc})
+	next := mkState(100, nil) // htlc vanished, no preimage, not expired
+	if err := validateTransition(current, next); err == nil {
+		t.Fatal("expected an error for an HTLC removed with neither a matching preimage nor an elapsed expiry")
+	}
+}
+
+func TestValidateTransition_AcceptsSettlementWithMatchingPreimage(t *testing.T) {
+	preimage := "secret"
+	hash := core.HashPreimage(preimage)
+	htlc := core.HTLC{Hash: hash, Amount: big.NewInt(10), Expiry: 99, Payer: "wallet-1", Payee: "wallet-2"}
+	current := mkState(90, []core.HTLC{htlc})
+
+	next := mkState(80, nil)
+	next.Preimage = preimage
+
+	if err := validateTransition(current, next); err != nil {
+		t.Fatalf("expected a legitimate settlement to validate, got: %v", err)
+	}
+}
+
+func TestValidateTransition_AcceptsTimeoutPastExpiry(t *testing.T) {
+	htlc := core.HTLC{Hash: "h1", Amount: big.NewInt(10), Expiry: 1, Payer: "wallet-1", Payee: "wallet-2"}
+	current := mkState(90, []core.HTLC{htlc})
+	current.Version = 1
+
+	next := mkState(90, nil) // TimeoutHTLC never touches Balance
+	next.Version = 2         // past the HTLC's expiry
+
+	if err := validateTransition(current, next); err != nil {
+		t.Fatalf("expected a legitimate timeout to validate, got: %v", err)
+	}
+}
@@ -0,0 +1,410 @@
+// Package notary decouples quorum signature collection from the client: in
+// cmd/demo's runHappyPath, the client itself round-robins RequestSignature
+// across the quorum and blocks until every signature is back. A Notary lets
+// a client instead submit an incomplete Request to any notary node, which
+// pools it (by state.Hash()) and gossips it to the rest of the notary
+// network so participants can attach their signatures asynchronously, over
+// whatever time that takes. Modeled on Neo's notary contract/module.
+package notary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// gossipType identifies a Request on the shared ports.GossipMessage bus.
+const gossipType = "notary.request"
+
+// sigGossipType identifies a signature attachment: one participant reporting
+// back that it signed a pooled Request, so every other notary replica
+// holding that Request (not just the one that happened to ask) can apply it
+// without each replica having to separately solicit the same participant.
+const sigGossipType = "notary.signature"
+
+// sigAttachment is sigGossipType's payload.
+type sigAttachment struct {
+	StateHash     string
+	ParticipantID string
+	Sig           []byte
+}
+
+var (
+	ErrPoolFull          = errors.New("notary: pool is full and no lower-fee request to evict")
+	ErrNotFound          = errors.New("notary: no request pooled for that state hash")
+	ErrDuplicateSigner   = errors.New("notary: participant already attached a signature")
+	ErrNoState           = errors.New("notary: request has no state")
+	ErrNoRequiredSigners = errors.New("notary: RequiredSigners must be positive")
+)
+
+// Request is a client's incomplete quorum signature collection.
+type Request struct {
+	State           *core.State
+	PartialSigs     map[string][]byte // participant NodeID -> signature over State.Hash()
+	RequiredSigners int
+	Deadline        time.Time   // zero means no deadline
+	Fallback        *core.State // published instead if Deadline passes before RequiredSigners is met
+	Fee             *big.Int    // ranks requests against each other when the pool is full
+}
+
+func (r *Request) complete() bool {
+	return len(r.PartialSigs) >= r.RequiredSigners
+}
+
+type poolEntry struct {
+	req         *Request
+	submittedAt time.Time
+}
+
+// Notary pools in-flight Requests, keyed by State.Hash(), and assembles +
+// publishes one once RequiredSigners have attached, or submits its Fallback
+// once Deadline passes first. The pool has a fixed capacity; once full, a
+// new Request only displaces the lowest-Fee entry already pooled.
+type Notary struct {
+	id      string
+	p2p     ports.P2PAdapter
+	metrics ports.Metrics
+
+	capacity int
+
+	// Signer, if set, lets this Notary act as a participant as well as a
+	// relay: whenever a Request naming n.id among State.Participants is
+	// pooled (via Submit or a gossiped Request), the Notary signs it itself
+	// and reports the signature back the same way a standalone participant
+	// node would, instead of only ever pooling and relaying other signers'
+	// attachments. Nil means this Notary never self-attaches.
+	Signer func(state *core.State) ([]byte, error)
+
+	mu   sync.Mutex
+	pool map[string]*poolEntry // State.Hash() -> entry
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// New creates a Notary, registers it with p2p under id so it can receive
+// gossiped Requests from other notaries, and starts its deadline sweep
+// (every sweepInterval). capacity <= 0 is treated as 1000.
+func New(id string, p2p ports.P2PAdapter, metrics ports.Metrics, capacity int, sweepInterval time.Duration) *Notary {
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	n := &Notary{
+		id:       id,
+		p2p:      p2p,
+		metrics:  metrics,
+		capacity: capacity,
+		pool:     make(map[string]*poolEntry),
+		stopCh:   make(chan struct{}),
+	}
+	p2p.RegisterNode(id, n)
+	go n.sweepLoop(sweepInterval)
+	return n
+}
+
+// Close stops the deadline sweep goroutine.
+func (n *Notary) Close() {
+	n.stopOnce.Do(func() { close(n.stopCh) })
+}
+
+// Submit pools req and broadcasts it to the rest of the notary network. If
+// the pool is already at capacity, req only displaces the lowest-Fee entry
+// currently pooled, and only if req.Fee is strictly higher; otherwise it's
+// rejected with ErrPoolFull rather than silently dropping someone else's
+// request.
+func (n *Notary) Submit(req *Request) error {
+	if req.State == nil {
+		return ErrNoState
+	}
+	if req.RequiredSigners <= 0 {
+		return ErrNoRequiredSigners
+	}
+	if req.PartialSigs == nil {
+		req.PartialSigs = make(map[string][]byte)
+	}
+	if req.Fee == nil {
+		req.Fee = big.NewInt(0)
+	}
+	key := req.State.Hash()
+
+	n.mu.Lock()
+	if _, exists := n.pool[key]; !exists && len(n.pool) >= n.capacity {
+		if !n.evictForLocked(req.Fee) {
+			n.mu.Unlock()
+			n.metrics.IncCounter("notary_pool_rejected", nil)
+			return ErrPoolFull
+		}
+	}
+	n.pool[key] = &poolEntry{req: req, submittedAt: time.Now()}
+	n.mu.Unlock()
+
+	n.metrics.IncCounter("notary_pool_submitted", nil)
+	if err := n.BroadcastNotaryRequest(req); err != nil {
+		return err
+	}
+	return n.selfAttachIfParticipant(key)
+}
+
+// BroadcastNotaryRequest gossips req to every other notary node, using the
+// same ports.P2PAdapter.Gossip primitive pkg/routing uses for channel ads
+// (see pkg/routing.Graph.Advertise), rather than a dedicated wire RPC: any
+// notary that receives it starts holding a replica, so a client isn't
+// pinned to the one notary it originally submitted to.
+func (n *Notary) BroadcastNotaryRequest(req *Request) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("notary: marshal request: %w", err)
+	}
+	return n.p2p.Gossip(ports.GossipMessage{Type: gossipType, Payload: payload})
+}
+
+// AttachSignature records participantID's signature against the pooled
+// request for stateHash and gossips the attachment to the rest of the
+// notary network, so every other replica holding that Request converges
+// without separately soliciting participantID itself. Once RequiredSigners
+// have attached, the assembled state is published and the entry is evicted.
+func (n *Notary) AttachSignature(stateHash, participantID string, sig []byte) error {
+	if err := n.attachLocal(stateHash, participantID, sig); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(sigAttachment{StateHash: stateHash, ParticipantID: participantID, Sig: sig})
+	if err != nil {
+		return fmt.Errorf("notary: marshal signature attachment: %w", err)
+	}
+	return n.p2p.Gossip(ports.GossipMessage{Type: sigGossipType, Payload: payload})
+}
+
+// attachLocal is AttachSignature's pool bookkeeping and finalize-if-complete
+// logic, split out so OnGossip can apply a signature attachment gossiped by
+// another replica without re-broadcasting it (the original Gossip call
+// already flooded every notary, this one included).
+func (n *Notary) attachLocal(stateHash, participantID string, sig []byte) error {
+	n.mu.Lock()
+	entry, ok := n.pool[stateHash]
+	if !ok {
+		n.mu.Unlock()
+		return ErrNotFound
+	}
+	if _, dup := entry.req.PartialSigs[participantID]; dup {
+		n.mu.Unlock()
+		return ErrDuplicateSigner
+	}
+	entry.req.PartialSigs[participantID] = sig
+	complete := entry.req.complete()
+	if complete {
+		delete(n.pool, stateHash)
+	}
+	n.mu.Unlock()
+
+	n.metrics.IncCounter("notary_signature_attached", nil)
+	if !complete {
+		return nil
+	}
+	return n.finalize(entry)
+}
+
+// selfAttachIfParticipant signs and attaches on this Notary's own behalf
+// when it's one of the pooled request's quorum participants: the path a
+// participant node takes to learn it owes a signature is simply holding the
+// Request at all (via Submit or a gossiped replica), rather than a separate
+// solicitation round-trip.
+func (n *Notary) selfAttachIfParticipant(stateHash string) error {
+	if n.Signer == nil {
+		return nil
+	}
+
+	n.mu.Lock()
+	entry, ok := n.pool[stateHash]
+	if !ok {
+		n.mu.Unlock()
+		return nil // already finalized by the time we got here
+	}
+	_, alreadySigned := entry.req.PartialSigs[n.id]
+	isParticipant := false
+	for _, p := range entry.req.State.Participants {
+		if p == n.id {
+			isParticipant = true
+			break
+		}
+	}
+	state := entry.req.State
+	n.mu.Unlock()
+
+	if alreadySigned || !isParticipant {
+		return nil
+	}
+
+	sig, err := n.Signer(state)
+	if err != nil {
+		return fmt.Errorf("notary: self-sign %s: %w", state.Wallet, err)
+	}
+	return n.AttachSignature(stateHash, n.id, sig)
+}
+
+// finalize assembles entry's completed state (signatures in Participants
+// order, so they line up the way simchain/mockchain expect) and publishes it.
+func (n *Notary) finalize(entry *poolEntry) error {
+	state := entry.req.State.Clone()
+	state.Sigs = nil
+	for _, participant := range state.Participants {
+		if sig, ok := entry.req.PartialSigs[participant]; ok {
+			state.Sigs = append(state.Sigs, sig)
+		}
+	}
+
+	n.metrics.IncCounter("notary_request_completed", nil)
+	n.recordLatency(entry.submittedAt)
+	return n.p2p.PublishState(state)
+}
+
+func (n *Notary) evictForLocked(fee *big.Int) bool {
+	var lowestKey string
+	var lowestFee *big.Int
+	for k, e := range n.pool {
+		if lowestFee == nil || e.req.Fee.Cmp(lowestFee) < 0 {
+			lowestKey, lowestFee = k, e.req.Fee
+		}
+	}
+	if lowestFee == nil || lowestFee.Cmp(fee) >= 0 {
+		return false
+	}
+	delete(n.pool, lowestKey)
+	n.metrics.IncCounter("notary_pool_evicted", nil)
+	return true
+}
+
+func (n *Notary) sweepLoop(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-ticker.C:
+			n.sweepExpired(time.Now())
+		}
+	}
+}
+
+// sweepExpired evicts every pooled request whose Deadline has passed,
+// submitting its Fallback (if any) in place of the never-completed state.
+func (n *Notary) sweepExpired(now time.Time) {
+	n.mu.Lock()
+	var expired []*poolEntry
+	for k, e := range n.pool {
+		if !e.req.Deadline.IsZero() && now.After(e.req.Deadline) {
+			expired = append(expired, e)
+			delete(n.pool, k)
+		}
+	}
+	n.mu.Unlock()
+
+	for _, e := range expired {
+		n.metrics.IncCounter("notary_request_expired", nil)
+		n.recordLatency(e.submittedAt)
+		if e.req.Fallback == nil {
+			continue
+		}
+		if err := n.p2p.PublishState(e.req.Fallback); err != nil {
+			fmt.Printf("[Notary %s] publish fallback for %s: %v\n", n.id, e.req.State.Wallet, err)
+		}
+	}
+}
+
+// recordLatency buckets how long a request sat in the pool before it was
+// resolved (completed or expired). ports.Metrics only exposes counters, not
+// histograms, so latency is reported as a labeled bucket count rather than a
+// raw duration value.
+func (n *Notary) recordLatency(submittedAt time.Time) {
+	n.metrics.IncCounter("notary_collection_latency", map[string]string{"bucket": latencyBucket(time.Since(submittedAt))})
+}
+
+func latencyBucket(d time.Duration) string {
+	switch {
+	case d < 100*time.Millisecond:
+		return "lt_100ms"
+	case d < time.Second:
+		return "lt_1s"
+	case d < 10*time.Second:
+		return "lt_10s"
+	default:
+		return "gte_10s"
+	}
+}
+
+// --- ports.NodeHandler ---
+//
+// A Notary registers itself as its own P2P participant so it can receive
+// gossiped Requests from other notaries; it doesn't track wallet state or
+// sign anything itself, so OnNewState/OnSignRequest are no-ops/refusals.
+
+func (n *Notary) OnNewState(state *core.State) {}
+
+func (n *Notary) OnSignRequest(state *core.State) ([]byte, error) {
+	return nil, errors.New("notary: does not sign requests itself")
+}
+
+func (n *Notary) OnGossip(msg ports.GossipMessage) {
+	switch msg.Type {
+	case gossipType:
+		n.onRequestGossip(msg.Payload)
+	case sigGossipType:
+		n.onSignatureGossip(msg.Payload)
+	}
+}
+
+func (n *Notary) onRequestGossip(payload []byte) {
+	var req Request
+	if err := json.Unmarshal(payload, &req); err != nil || req.State == nil {
+		return
+	}
+	key := req.State.Hash()
+
+	n.mu.Lock()
+	if _, exists := n.pool[key]; exists {
+		// Already holding a (possibly further-along) replica; don't clobber
+		// locally attached signatures with a stale gossiped copy.
+		n.mu.Unlock()
+		return
+	}
+	if len(n.pool) >= n.capacity {
+		n.mu.Unlock()
+		return
+	}
+	n.pool[key] = &poolEntry{req: &req, submittedAt: time.Now()}
+	n.mu.Unlock()
+
+	if err := n.selfAttachIfParticipant(key); err != nil {
+		fmt.Printf("[Notary %s] self-attach on gossiped request for %s: %v\n", n.id, req.State.Wallet, err)
+	}
+}
+
+// onSignatureGossip applies a signature another replica already validated
+// and attached locally; it never re-broadcasts (see attachLocal) or treats
+// ErrNotFound/ErrDuplicateSigner as failures worth logging, since every
+// notary in the network receives the same gossip and most won't be holding
+// that Request at all.
+func (n *Notary) onSignatureGossip(payload []byte) {
+	var att sigAttachment
+	if err := json.Unmarshal(payload, &att); err != nil {
+		return
+	}
+	n.attachLocal(att.StateHash, att.ParticipantID, att.Sig)
+}
+
+// noopMetrics discards every counter; the default when metrics is nil.
+type noopMetrics struct{}
+
+func (noopMetrics) IncCounter(name string, labels map[string]string) {}
@@ -0,0 +1,98 @@
+package notary
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mod/clearnet/pkg/adapters/mockp2p"
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// signerFor returns a Signer that just stamps a deterministic signature, the
+// same fake format node.Node.validateAndSign uses, standing in for a
+// participant node's real signing logic.
+func signerFor(id string) func(*core.State) ([]byte, error) {
+	return func(state *core.State) ([]byte, error) {
+		return []byte(fmt.Sprintf("sig:%s:%s", id, state.Hash())), nil
+	}
+}
+
+func TestNotary_EndToEndSignatureCollection(t *testing.T) {
+	p2p := mockp2p.NewMockP2P()
+
+	relay := New("relay", p2p, nil, 100, time.Minute)
+	defer relay.Close()
+
+	participants := []string{"node-0", "node-1", "node-2"}
+	var signers []*Notary
+	for _, id := range participants {
+		notary := New(id, p2p, nil, 100, time.Minute)
+		notary.Signer = signerFor(id)
+		signers = append(signers, notary)
+		defer notary.Close()
+	}
+
+	state := &core.State{
+		Wallet:       "wallet-1",
+		Token:        "token-usdt",
+		Version:      2,
+		Balance:      big.NewInt(20),
+		Participants: participants,
+	}
+
+	req := &Request{State: state, RequiredSigners: len(participants), Fee: big.NewInt(1)}
+	if err := relay.Submit(req); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		published, err := p2p.GetLatestState(state.Wallet)
+		if err == nil && len(published.Sigs) == len(participants) {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for quorum signatures to finalize and publish (last err: %v)", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestNotary_SweepExpiredPublishesFallback(t *testing.T) {
+	p2p := mockp2p.NewMockP2P()
+	relay := New("relay", p2p, nil, 100, time.Hour) // long sweep interval; we call sweepExpired directly
+	defer relay.Close()
+
+	state := &core.State{
+		Wallet:       "wallet-2",
+		Token:        "token-usdt",
+		Version:      2,
+		Balance:      big.NewInt(20),
+		Participants: []string{"node-0"},
+	}
+	fallback := &core.State{
+		Wallet:       "wallet-2",
+		Token:        "token-usdt",
+		Version:      1,
+		Balance:      big.NewInt(100),
+		Participants: []string{"node-0"},
+	}
+
+	req := &Request{State: state, RequiredSigners: 1, Deadline: time.Now().Add(-time.Second), Fallback: fallback}
+	if err := relay.Submit(req); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	relay.sweepExpired(time.Now())
+
+	published, err := p2p.GetLatestState("wallet-2")
+	if err != nil {
+		t.Fatalf("GetLatestState: %v", err)
+	}
+	if published.Version != 1 {
+		t.Fatalf("expected the fallback (version 1) to have been published, got version %d", published.Version)
+	}
+}
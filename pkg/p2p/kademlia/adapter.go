@@ -0,0 +1,725 @@
+package kademlia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/p2p/quota"
+	"github.com/mod/clearnet/pkg/ports"
+	"github.com/mod/clearnet/pkg/registry/cache"
+)
+
+// maxContacts bounds how many known contacts Gossip will flood a message
+// to; large enough to mean "everyone we know" for any routing-table size
+// this prototype runs with.
+const maxContacts = 4096
+
+// Adapter implements ports.P2PAdapter on top of a Kademlia DHT. One Adapter
+// corresponds to one physical node/process, listening on a single address.
+type Adapter struct {
+	self      Contact
+	id        ID
+	table     *RoutingTable
+	transport Transport
+	registry  ports.Registry
+
+	// Timing controls RPC timeouts and the republish/refresh maintenance
+	// loops started by StartMaintenance.
+	Timing Timing
+
+	// Limiter, if set, enforces per-peer quotas on inbound RPCs and
+	// outbound RequestSignature calls. Nil means unlimited.
+	Limiter *quota.Limiter
+
+	mu      sync.RWMutex
+	values  map[ID]storedValue // content-addressed store (keyed by State.Hash() or a wallet pointer key)
+	handler ports.NodeHandler
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// storedValue is a DHT record: the raw encoded core.State, plus the
+// bookkeeping republishAndExpire needs to keep the store fresh.
+type storedValue struct {
+	State *core.State
+	// StoredAt is when this record was last (re)written locally.
+	StoredAt time.Time
+	// Origin is true for records this node itself published (as opposed to
+	// ones it's merely holding as a replica for another node): only origin
+	// records get periodically republished.
+	Origin bool
+}
+
+// NewAdapter creates a Kademlia P2PAdapter listening as `id` at `addr`.
+// registry is used to resolve bootstrap peers discovered via
+// ports.Registry.GetNodes; it may be nil if peers are added manually via
+// Bootstrap.
+func NewAdapter(id ID, addr string, transport Transport, registry ports.Registry) *Adapter {
+	return &Adapter{
+		self:      Contact{ID: id, Addr: addr},
+		id:        id,
+		table:     NewRoutingTable(id),
+		transport: transport,
+		registry:  registry,
+		Timing:    DefaultTiming(),
+		values:    make(map[ID]storedValue),
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Listen starts serving Kademlia RPCs on the adapter's address.
+func (a *Adapter) Listen() error {
+	return a.transport.Serve(a.self.Addr, a.handleRPC)
+}
+
+// Close stops serving RPCs and any running maintenance loops.
+func (a *Adapter) Close() error {
+	a.StopMaintenance()
+	return a.transport.Close()
+}
+
+// Bootstrap seeds the routing table with known contacts (e.g. fetched via
+// ports.Registry.GetNodes) and performs a self-lookup to populate buckets.
+func (a *Adapter) Bootstrap(ctx context.Context, seeds []Contact) {
+	for _, c := range seeds {
+		a.updateContact(c)
+	}
+	a.FindNode(a.id)
+}
+
+// StartMaintenance launches the background republish and bucket-refresh
+// loops, using the intervals in a.Timing. It returns immediately; call
+// StopMaintenance (or Close) to stop them. If registry is a *cache.Cache,
+// it also starts watchRegistryChanges so the routing table reacts to node
+// add/remove/update events as they're pushed, instead of only discovering
+// them the next time refreshStaleBuckets happens to probe that bucket.
+func (a *Adapter) StartMaintenance() {
+	go a.republishLoop()
+	go a.refreshLoop()
+	if watchable, ok := a.registry.(*cache.Cache); ok {
+		go a.watchRegistryChanges(watchable)
+	}
+}
+
+// watchRegistryChanges keeps the routing table in sync with watchable's
+// change feed until StopMaintenance closes a.stopCh: a newly (re)registered
+// or updated node is added as a contact the same way a bootstrap seed would
+// be, and an unregistered node is evicted immediately rather than waiting
+// for it to fail a liveness probe.
+func (a *Adapter) watchRegistryChanges(watchable *cache.Cache) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := watchable.WatchNodes(ctx)
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			switch ev.Type {
+			case cache.NodeAdded, cache.NodeUpdated:
+				if ID(ev.Node.ID) == a.id {
+					continue
+				}
+				a.updateContact(Contact{ID: ID(ev.Node.ID), Addr: ev.Node.Address})
+			case cache.NodeRemoved:
+				a.table.Remove(ID(ev.Node.ID))
+			}
+		}
+	}
+}
+
+// StopMaintenance stops any loops started by StartMaintenance. Safe to call
+// more than once, and safe to call even if StartMaintenance never was.
+func (a *Adapter) StopMaintenance() {
+	a.stopOnce.Do(func() { close(a.stopCh) })
+}
+
+func (a *Adapter) republishLoop() {
+	ticker := time.NewTicker(a.Timing.RepublishInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			a.republishAndExpire()
+		}
+	}
+}
+
+// republishAndExpire re-announces every locally-originated record to the
+// network and drops any record (origin or replica) that hasn't been
+// refreshed within Timing.ExpireAfter.
+func (a *Adapter) republishAndExpire() {
+	now := time.Now()
+
+	type record struct {
+		key   ID
+		state *core.State
+	}
+	var stale []record
+
+	a.mu.Lock()
+	for key, v := range a.values {
+		if now.Sub(v.StoredAt) > a.Timing.ExpireAfter {
+			delete(a.values, key)
+			continue
+		}
+		if v.Origin {
+			stale = append(stale, record{key: key, state: v.State})
+		}
+	}
+	a.mu.Unlock()
+
+	for _, r := range stale {
+		payload, err := json.Marshal(StorePayload{Key: r.key, State: r.state})
+		if err != nil {
+			continue
+		}
+		for _, c := range a.FindNode(r.key) {
+			go a.send(c.Addr, RPCStore, payload)
+		}
+		a.touchLocal(r.key)
+	}
+}
+
+func (a *Adapter) refreshLoop() {
+	ticker := time.NewTicker(a.Timing.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			a.refreshStaleBuckets()
+		}
+	}
+}
+
+// refreshStaleBuckets runs a FindNode against a random ID in each bucket
+// that hasn't been touched within Timing.RefreshAfter, per the standard
+// Kademlia bucket-refresh trick.
+func (a *Adapter) refreshStaleBuckets() {
+	for _, idx := range a.table.StaleBuckets(a.Timing.RefreshAfter) {
+		go a.FindNode(randomIDInBucket(a.id, idx))
+	}
+}
+
+// BootstrapFromRegistry dials the registry for known peers and bootstraps
+// from them.
+func (a *Adapter) BootstrapFromRegistry(ctx context.Context) error {
+	if a.registry == nil {
+		return fmt.Errorf("kademlia: no registry configured")
+	}
+	nodes, err := a.registry.GetNodes(ctx, 0, 256)
+	if err != nil {
+		return err
+	}
+	seeds := make([]Contact, 0, len(nodes))
+	for _, n := range nodes {
+		if ID(n.ID) == a.id {
+			continue
+		}
+		seeds = append(seeds, Contact{ID: ID(n.ID), Addr: n.Address})
+	}
+	a.Bootstrap(ctx, seeds)
+	return nil
+}
+
+// --- ports.P2PAdapter ---
+
+// PublishState DHT-stores state under its content hash (State.Hash()) and
+// under a wallet pointer key, so GetLatestState can find the newest version
+// without knowing the content hash in advance.
+func (a *Adapter) PublishState(state *core.State) error {
+	contentKey, err := IDFromHex(state.Hash())
+	if err != nil {
+		return fmt.Errorf("kademlia: invalid state hash: %w", err)
+	}
+	pointerKey := walletKey(state.Wallet)
+
+	// Always keep a local copy; a real node participating in the network is
+	// itself a valid replica. Both keys are origin records here, so
+	// republishAndExpire keeps re-announcing them.
+	a.storeLocal(contentKey, state, true)
+	a.storeLocal(pointerKey, state, true)
+
+	targets := a.FindNode(contentKey)
+	payload, err := json.Marshal(StorePayload{Key: contentKey, State: state})
+	if err != nil {
+		return err
+	}
+	pointerPayload, err := json.Marshal(StorePayload{Key: pointerKey, State: state})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range targets {
+		go a.send(c.Addr, RPCStore, payload)
+		go a.send(c.Addr, RPCStore, pointerPayload)
+	}
+	return nil
+}
+
+// GetLatestState performs an iterative FindValue lookup keyed by the
+// wallet's pointer key and returns the highest-Version state seen across the
+// K closest nodes.
+func (a *Adapter) GetLatestState(wallet string) (*core.State, error) {
+	key := walletKey(wallet)
+
+	var best *core.State
+	if local, ok := a.lookupLocal(key); ok {
+		best = local
+	}
+
+	targets := a.FindNode(key)
+	payload, err := json.Marshal(FindValuePayload{Key: key})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range targets {
+		resp, err := a.send(c.Addr, RPCFindValue, payload)
+		if err != nil {
+			continue
+		}
+		var fv FindValueResponse
+		if err := json.Unmarshal(resp.Payload, &fv); err != nil || fv.State == nil {
+			continue
+		}
+		if best == nil || fv.State.Version > best.Version {
+			best = fv.State
+		}
+	}
+
+	if best == nil {
+		return nil, errValueNotFound
+	}
+	return best, nil
+}
+
+// RequestSignature performs a direct RPC to nodeID (a hex-encoded Kademlia
+// ID), asking it to sign state.
+func (a *Adapter) RequestSignature(nodeID string, state *core.State) ([]byte, error) {
+	id, err := IDFromHex(nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("kademlia: invalid node id %q: %w", nodeID, err)
+	}
+
+	if id == a.id {
+		if a.handler == nil {
+			return nil, errUnknownNode
+		}
+		return a.handler.OnSignRequest(state)
+	}
+
+	contact, ok := a.resolveContact(id)
+	if !ok {
+		return nil, errUnknownNode
+	}
+
+	if a.Limiter != nil {
+		release, err := a.Limiter.AdmitSignatureRequest(nodeID)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	payload, err := json.Marshal(SignRequestPayload{State: state})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.send(contact.Addr, RPCSignRequest, payload)
+	if err != nil {
+		return nil, err
+	}
+	var sr SignResponse
+	if err := json.Unmarshal(resp.Payload, &sr); err != nil {
+		return nil, err
+	}
+	if sr.Error != "" {
+		return nil, fmt.Errorf("kademlia: remote sign error: %s", sr.Error)
+	}
+	return sr.Signature, nil
+}
+
+// RegisterNode wires handler into the RPC dispatcher for this adapter's own
+// node ID. nodeID must be the hex encoding of the adapter's Kademlia ID.
+func (a *Adapter) RegisterNode(nodeID string, handler ports.NodeHandler) {
+	id, err := IDFromHex(nodeID)
+	if err != nil || id != a.id {
+		// A mismatched ID means the caller is trying to register a handler
+		// for a *different* logical node than the one this adapter serves.
+		// Real P2P is one process per node, so we still install it locally:
+		// direct RequestSignature calls for our own ID fall through to it.
+		a.mu.Lock()
+		a.handler = handler
+		a.mu.Unlock()
+		return
+	}
+	a.mu.Lock()
+	a.handler = handler
+	a.mu.Unlock()
+}
+
+// Gossip floods msg to every contact this adapter currently knows about, via
+// a single best-effort RPCGossip to each. Unlike PublishState/FindNode, this
+// doesn't target the nodes closest to some key: a gossiped announcement
+// (e.g. a routing channel ad) is relevant network-wide, not to one key's
+// neighborhood.
+func (a *Adapter) Gossip(msg ports.GossipMessage) error {
+	payload, err := json.Marshal(GossipPayload{Msg: msg})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range a.table.Closest(a.id, maxContacts) {
+		go a.send(c.Addr, RPCGossip, payload)
+	}
+	return nil
+}
+
+// --- lookup ---
+
+// FindNode performs an iterative node lookup for target, returning up to K
+// contacts sorted by distance. Each round queries up to Alpha unqueried
+// contacts from the shortlist concurrently (each bounded by Timing.RPCTimeout);
+// a contact that fails to respond is evicted from the routing table and
+// dropped from further consideration. The lookup terminates once the K
+// closest known contacts have all been queried, or once a round completes
+// without turning up anyone closer than the closest contact already known.
+func (a *Adapter) FindNode(target ID) []Contact {
+	shortlist := a.table.Closest(target, K)
+	sortByDistance(shortlist, target)
+	queried := map[ID]bool{a.id: true}
+	failed := map[ID]bool{}
+
+	for {
+		var round []Contact
+		for _, c := range shortlist {
+			if queried[c.ID] {
+				continue
+			}
+			round = append(round, c)
+			if len(round) == Alpha {
+				break
+			}
+		}
+		if len(round) == 0 {
+			break
+		}
+
+		var closestBefore ID
+		if len(shortlist) > 0 {
+			closestBefore = shortlist[0].ID
+		}
+
+		type reply struct {
+			contact Contact
+			peers   []Contact
+			ok      bool
+		}
+		replies := make(chan reply, len(round))
+		for _, c := range round {
+			queried[c.ID] = true
+			go func(c Contact) {
+				peers, err := a.rpcFindNode(c, target)
+				replies <- reply{contact: c, peers: peers, ok: err == nil}
+			}(c)
+		}
+		for i := 0; i < len(round); i++ {
+			r := <-replies
+			if !r.ok {
+				failed[r.contact.ID] = true
+				a.table.Remove(r.contact.ID)
+				continue
+			}
+			a.updateContact(r.contact)
+			for _, p := range r.peers {
+				if p.ID == a.id || failed[p.ID] || contains(shortlist, p.ID) {
+					continue
+				}
+				shortlist = append(shortlist, p)
+			}
+		}
+
+		sortByDistance(shortlist, target)
+		if len(shortlist) > K {
+			shortlist = shortlist[:K]
+		}
+
+		allQueried := true
+		for _, c := range shortlist {
+			if !queried[c.ID] {
+				allQueried = false
+				break
+			}
+		}
+		noCloser := len(shortlist) == 0 || shortlist[0].ID == closestBefore
+		if allQueried || noCloser {
+			break
+		}
+	}
+
+	return shortlist
+}
+
+func contains(cs []Contact, id ID) bool {
+	for _, c := range cs {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Adapter) resolveContact(id ID) (Contact, bool) {
+	for _, c := range a.table.Closest(id, K) {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Contact{}, false
+}
+
+// updateContact records that c was just seen, wiring RoutingTable.Update's
+// liveness probe to a real RPCPing.
+func (a *Adapter) updateContact(c Contact) {
+	a.table.Update(c, a.pingContact)
+}
+
+// pingContact reports whether c answers an RPCPing within Timing.RPCTimeout.
+func (a *Adapter) pingContact(c Contact) bool {
+	_, err := a.send(c.Addr, RPCPing, nil)
+	return err == nil
+}
+
+// --- local store ---
+
+// storeLocal writes state under key, refreshing StoredAt so
+// republishAndExpire won't prematurely expire it. origin marks whether this
+// node is the one that originally published the record (see storedValue).
+func (a *Adapter) storeLocal(key ID, state *core.State, origin bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if existing, ok := a.values[key]; ok && existing.State.Version >= state.Version {
+		existing.StoredAt = time.Now()
+		a.values[key] = existing
+		return
+	}
+	a.values[key] = storedValue{State: state, StoredAt: time.Now(), Origin: origin}
+}
+
+// touchLocal refreshes an existing record's StoredAt without changing its
+// content, used when re-Put'ing an origin key that hasn't actually changed.
+func (a *Adapter) touchLocal(key ID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if v, ok := a.values[key]; ok {
+		v.StoredAt = time.Now()
+		a.values[key] = v
+	}
+}
+
+func (a *Adapter) lookupLocal(key ID) (*core.State, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	v, ok := a.values[key]
+	if !ok {
+		return nil, false
+	}
+	return v.State, true
+}
+
+// walletKey is the DHT pointer key GetLatestState/PublishState use to locate
+// the newest state for a wallet. It deliberately ignores token: both methods
+// must derive the same key from the same wallet, and ports.P2PAdapter's
+// GetLatestState only ever receives a wallet.
+func walletKey(wallet string) ID {
+	return HashKey("wallet:" + wallet)
+}
+
+// --- RPC client helpers ---
+
+// send delivers an RPC and waits up to Timing.RPCTimeout for a reply. A zero
+// RPCTimeout disables the deadline, which is useful for in-process test
+// transports that never block.
+func (a *Adapter) send(addr string, t RPCType, payload json.RawMessage) (Envelope, error) {
+	msg := Envelope{Type: t, Sender: a.self, Payload: payload}
+	if a.Timing.RPCTimeout <= 0 {
+		return a.transport.Send(addr, msg)
+	}
+
+	type result struct {
+		env Envelope
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		env, err := a.transport.Send(addr, msg)
+		done <- result{env, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.env, r.err
+	case <-time.After(a.Timing.RPCTimeout):
+		return Envelope{}, errNoResponse
+	}
+}
+
+func (a *Adapter) rpcFindNode(c Contact, target ID) ([]Contact, error) {
+	payload, err := json.Marshal(FindNodePayload{Target: target})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.send(c.Addr, RPCFindNode, payload)
+	if err != nil {
+		return nil, err
+	}
+	var fn FindNodeResponse
+	if err := json.Unmarshal(resp.Payload, &fn); err != nil {
+		return nil, err
+	}
+	return fn.Contacts, nil
+}
+
+// admitServerRPC checks the server-side quota (if a Limiter is configured)
+// for an inbound RPC from sender. release is always safe to call, even when
+// no Limiter is set.
+func (a *Adapter) admitServerRPC(sender ID) (release func(), err error) {
+	if a.Limiter == nil {
+		return func() {}, nil
+	}
+	return a.Limiter.AdmitServer(sender.String())
+}
+
+// --- RPC server dispatch ---
+
+func (a *Adapter) handleRPC(env Envelope) Envelope {
+	a.updateContact(env.Sender)
+
+	switch env.Type {
+	case RPCPing:
+		return Envelope{Type: RPCPing, Sender: a.self}
+
+	case RPCStore:
+		var p StorePayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil {
+			a.storeLocal(p.Key, p.State, false)
+		}
+		return Envelope{Type: RPCStore, Sender: a.self}
+
+	case RPCFindNode:
+		var p FindNodePayload
+		_ = json.Unmarshal(env.Payload, &p)
+		contacts := a.table.Closest(p.Target, K)
+		body, _ := json.Marshal(FindNodeResponse{Contacts: contacts})
+		return Envelope{Type: RPCFindNode, Sender: a.self, Payload: body}
+
+	case RPCFindValue:
+		var p FindValuePayload
+		_ = json.Unmarshal(env.Payload, &p)
+		state, _ := a.lookupLocal(p.Key)
+		body, _ := json.Marshal(FindValueResponse{State: state})
+		return Envelope{Type: RPCFindValue, Sender: a.self, Payload: body}
+
+	case RPCSignRequest:
+		var p SignRequestPayload
+		resp := SignResponse{}
+		if release, err := a.admitServerRPC(env.Sender.ID); err != nil {
+			resp.Error = err.Error()
+		} else {
+			defer release()
+			if err := json.Unmarshal(env.Payload, &p); err != nil {
+				resp.Error = err.Error()
+			} else if a.handler == nil {
+				resp.Error = "no handler registered"
+			} else {
+				sig, err := a.handler.OnSignRequest(p.State)
+				if err != nil {
+					resp.Error = err.Error()
+				} else {
+					resp.Signature = sig
+				}
+			}
+		}
+		body, _ := json.Marshal(resp)
+		return Envelope{Type: RPCSignRequest, Sender: a.self, Payload: body}
+
+	case RPCNewState:
+		release, err := a.admitServerRPC(env.Sender.ID)
+		if err != nil {
+			return Envelope{Type: RPCNewState, Sender: a.self}
+		}
+		defer release()
+
+		var p SignRequestPayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil && a.handler != nil {
+			a.handler.OnNewState(p.State)
+		}
+		return Envelope{Type: RPCNewState, Sender: a.self}
+
+	case RPCGossip:
+		release, err := a.admitServerRPC(env.Sender.ID)
+		if err != nil {
+			return Envelope{Type: RPCGossip, Sender: a.self}
+		}
+		defer release()
+
+		var p GossipPayload
+		if err := json.Unmarshal(env.Payload, &p); err == nil && a.handler != nil {
+			a.handler.OnGossip(p.Msg)
+		}
+		return Envelope{Type: RPCGossip, Sender: a.self}
+
+	default:
+		return Envelope{Sender: a.self}
+	}
+}
+
+// --- RPC payload/response types ---
+
+type StorePayload struct {
+	Key   ID
+	State *core.State
+}
+
+type FindNodePayload struct {
+	Target ID
+}
+
+type FindNodeResponse struct {
+	Contacts []Contact
+}
+
+type FindValuePayload struct {
+	Key ID
+}
+
+type FindValueResponse struct {
+	State *core.State
+}
+
+type SignRequestPayload struct {
+	State *core.State
+}
+
+type GossipPayload struct {
+	Msg ports.GossipMessage
+}
+
+type SignResponse struct {
+	Signature []byte
+	Error     string
+}
@@ -0,0 +1,49 @@
+package kademlia
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mod/clearnet/pkg/adapters/mockregistry"
+	"github.com/mod/clearnet/pkg/registry/cache"
+)
+
+// noopTransport never actually dials anywhere: these tests only exercise
+// watchRegistryChanges, which updates the routing table directly and never
+// touches the wire.
+type noopTransport struct{}
+
+func (noopTransport) Send(addr string, msg Envelope) (Envelope, error)         { return Envelope{}, nil }
+func (noopTransport) Serve(addr string, handler func(Envelope) Envelope) error { return nil }
+func (noopTransport) Close() error                                             { return nil }
+
+func TestAdapter_WatchRegistryChangesAddsAndRemovesContacts(t *testing.T) {
+	reg := mockregistry.New()
+	c := cache.New(reg, 16, 1000, 0.01)
+	defer c.Close()
+
+	selfID := HashKey("self")
+	a := NewAdapter(selfID, "self:9000", noopTransport{}, c)
+	a.StartMaintenance()
+	defer a.StopMaintenance()
+
+	var nodeID [32]byte
+	nodeID[0] = 0x42
+	if err := reg.Register(context.Background(), nodeID, "peer.example", 9001, big.NewInt(250000)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		if contains(a.table.Closest(ID(nodeID), K), ID(nodeID)) {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("registered node never showed up in the routing table")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package kademlia
+
+import "errors"
+
+var (
+	errInvalidIDLength = errors.New("kademlia: invalid id length")
+	errNoResponse      = errors.New("kademlia: peer did not respond")
+	errValueNotFound   = errors.New("kademlia: value not found")
+	errUnknownNode     = errors.New("kademlia: unknown node id")
+)
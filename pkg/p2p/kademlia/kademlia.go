@@ -0,0 +1,99 @@
+// Package kademlia implements ports.P2PAdapter on top of a real Kademlia DHT.
+//
+// This started life as the self-contained demo in cmd/kad/main.go. It is now
+// wired into the rest of the module: node IDs are the same 32-byte
+// SHA-256-derived identifiers used for ports.NodeInfo.ID, values stored in the
+// DHT are core.State records, and peers talk to each other over a pluggable
+// Transport instead of an in-process map.
+package kademlia
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// IDLength is the size of a Kademlia ID in bytes. It matches ports.NodeInfo.ID
+// and core.State.Hash() (both SHA-256), so state hashes and node IDs share the
+// same keyspace.
+const IDLength = 32
+
+// K is the bucket size / replication factor.
+const K = 20
+
+// Alpha is the lookup concurrency parameter.
+const Alpha = 3
+
+// ID is a Kademlia identifier: a node ID or a content/lookup key.
+type ID [IDLength]byte
+
+// HashKey hashes an arbitrary key (e.g. "wallet:token") into the ID space.
+func HashKey(key string) ID {
+	return ID(sha256.Sum256([]byte(key)))
+}
+
+// IDFromHex decodes a hex-encoded hash (e.g. the output of core.State.Hash())
+// into an ID.
+func IDFromHex(s string) (ID, error) {
+	var id ID
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return id, err
+	}
+	if len(b) != IDLength {
+		return id, errInvalidIDLength
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+func (id ID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Xor returns the XOR distance between two IDs.
+func Xor(a, b ID) ID {
+	var res ID
+	for i := 0; i < IDLength; i++ {
+		res[i] = a[i] ^ b[i]
+	}
+	return res
+}
+
+// Less reports whether distance a is closer than distance b.
+func lessDistance(a, b ID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+// bucketIndex returns the k-bucket index for id relative to self (number of
+// leading zero bits in the XOR distance).
+func bucketIndex(self, id ID) int {
+	dist := Xor(self, id)
+	for i := 0; i < IDLength; i++ {
+		for j := 0; j < 8; j++ {
+			if (dist[i]>>uint8(7-j))&0x1 != 0 {
+				return i*8 + j
+			}
+		}
+	}
+	return IDLength*8 - 1
+}
+
+// Contact is a routing-table entry: an addressable peer.
+type Contact struct {
+	ID   ID
+	Addr string
+}
+
+// sortByDistance sorts contacts by XOR distance to target, closest first.
+func sortByDistance(contacts []Contact, target ID) {
+	sort.Slice(contacts, func(i, j int) bool {
+		di := Xor(contacts[i].ID, target)
+		dj := Xor(contacts[j].ID, target)
+		return lessDistance(di, dj)
+	})
+}
+
+// See kbucket.go for RoutingTable: K-buckets with LRU eviction, liveness
+// probing and a replacement cache.
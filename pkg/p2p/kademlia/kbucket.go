@@ -0,0 +1,213 @@
+package kademlia
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// RoutingTable holds the K-buckets for a local node, following standard
+// Kademlia LRU eviction: each bucket is ordered oldest-seen (index 0, the
+// "head") to freshest-seen (the "tail"). When a bucket is full and a new
+// contact shows up, the head is probed for liveness (see Update) instead of
+// being dropped outright — a live head stays and the newcomer is stashed in
+// a per-bucket replacement cache; a dead head is evicted in its favor.
+type RoutingTable struct {
+	self ID
+
+	mu           sync.Mutex
+	buckets      [IDLength * 8][]Contact  // ordered oldest..freshest
+	replacements [IDLength * 8][]Contact  // ordered oldest..freshest, capped at K
+	touched      [IDLength * 8]time.Time  // last time this bucket changed, for refresh
+}
+
+// NewRoutingTable creates an empty routing table for self.
+func NewRoutingTable(self ID) *RoutingTable {
+	return &RoutingTable{self: self}
+}
+
+// BucketIndex returns which bucket id belongs to relative to self.
+func (rt *RoutingTable) BucketIndex(id ID) int {
+	return bucketIndex(rt.self, id)
+}
+
+// Update records that a contact was just seen. probe is used only when the
+// contact's bucket is full and the newcomer isn't already known: it's
+// invoked with the bucket's head (the least-recently-seen contact) and
+// should return whether that peer is still reachable. A live head is kept
+// and moved to the tail, with c stashed as a replacement; a dead (or nil
+// probe) head is evicted in favor of c. probe is called without holding the
+// table's lock, so RPCs are safe to issue from it.
+func (rt *RoutingTable) Update(c Contact, probe func(Contact) bool) {
+	if c.ID == rt.self {
+		return
+	}
+	idx := rt.BucketIndex(c.ID)
+
+	rt.mu.Lock()
+	bucket := rt.buckets[idx]
+	for i, existing := range bucket {
+		if existing.ID == c.ID {
+			rt.buckets[idx] = append(append(bucket[:i:i], bucket[i+1:]...), c)
+			rt.touched[idx] = time.Now()
+			rt.mu.Unlock()
+			return
+		}
+	}
+	if len(bucket) < K {
+		rt.buckets[idx] = append(bucket, c)
+		rt.touched[idx] = time.Now()
+		rt.mu.Unlock()
+		return
+	}
+	head := bucket[0]
+	rt.mu.Unlock()
+
+	if probe != nil && probe(head) {
+		rt.mu.Lock()
+		rt.touchLocked(idx, head.ID)
+		rt.addReplacementLocked(idx, c)
+		rt.mu.Unlock()
+		return
+	}
+
+	rt.mu.Lock()
+	rt.evictAndInsertLocked(idx, head.ID, c)
+	rt.mu.Unlock()
+}
+
+// touchLocked moves an existing bucket member to the tail (freshest).
+func (rt *RoutingTable) touchLocked(idx int, id ID) {
+	bucket := rt.buckets[idx]
+	for i, existing := range bucket {
+		if existing.ID == id {
+			c := existing
+			rt.buckets[idx] = append(append(bucket[:i:i], bucket[i+1:]...), c)
+			rt.touched[idx] = time.Now()
+			return
+		}
+	}
+}
+
+// addReplacementLocked stashes c in its bucket's replacement cache, most
+// recently seen at the tail, capped at K entries (oldest dropped first).
+func (rt *RoutingTable) addReplacementLocked(idx int, c Contact) {
+	repl := rt.replacements[idx]
+	for i, existing := range repl {
+		if existing.ID == c.ID {
+			repl = append(repl[:i], repl[i+1:]...)
+			break
+		}
+	}
+	repl = append(repl, c)
+	if len(repl) > K {
+		repl = repl[len(repl)-K:]
+	}
+	rt.replacements[idx] = repl
+}
+
+// evictAndInsertLocked removes deadID from its bucket (if still present —
+// the liveness probe can race a concurrent Remove) and inserts c at the
+// tail.
+func (rt *RoutingTable) evictAndInsertLocked(idx int, deadID ID, c Contact) {
+	bucket := rt.buckets[idx]
+	for i, existing := range bucket {
+		if existing.ID == deadID {
+			bucket = append(bucket[:i:i], bucket[i+1:]...)
+			break
+		}
+	}
+	rt.buckets[idx] = append(bucket, c)
+	rt.touched[idx] = time.Now()
+}
+
+// Remove drops a contact from its bucket, promoting the freshest
+// replacement (if any) into the vacated slot.
+func (rt *RoutingTable) Remove(id ID) {
+	idx := rt.BucketIndex(id)
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	bucket := rt.buckets[idx]
+	for i, existing := range bucket {
+		if existing.ID == id {
+			rt.buckets[idx] = append(bucket[:i:i], bucket[i+1:]...)
+			rt.touched[idx] = time.Now()
+			rt.promoteReplacementLocked(idx)
+			return
+		}
+	}
+}
+
+func (rt *RoutingTable) promoteReplacementLocked(idx int) {
+	repl := rt.replacements[idx]
+	if len(repl) == 0 || len(rt.buckets[idx]) >= K {
+		return
+	}
+	promoted := repl[len(repl)-1]
+	rt.replacements[idx] = repl[:len(repl)-1]
+	rt.buckets[idx] = append(rt.buckets[idx], promoted)
+}
+
+// Closest returns the `count` contacts closest to target across all
+// buckets.
+func (rt *RoutingTable) Closest(target ID, count int) []Contact {
+	rt.mu.Lock()
+	var candidates []Contact
+	for _, bucket := range rt.buckets {
+		candidates = append(candidates, bucket...)
+	}
+	rt.mu.Unlock()
+
+	sortByDistance(candidates, target)
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+	return candidates
+}
+
+// StaleBuckets returns the indices of non-empty buckets that haven't been
+// touched (had a contact added, refreshed or evicted) within maxAge — the
+// candidates for a background bucket refresh.
+func (rt *RoutingTable) StaleBuckets(maxAge time.Duration) []int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	var stale []int
+	cutoff := time.Now().Add(-maxAge)
+	for idx, bucket := range rt.buckets {
+		if len(bucket) == 0 {
+			continue
+		}
+		if rt.touched[idx].Before(cutoff) {
+			stale = append(stale, idx)
+		}
+	}
+	return stale
+}
+
+// randomIDInBucket returns a random ID that falls in bucket idx relative to
+// self, i.e. one whose XOR distance to self has exactly idx leading zero
+// bits. This is the standard trick for picking a lookup target that
+// refreshes a specific bucket.
+func randomIDInBucket(self ID, idx int) ID {
+	var id ID
+	copy(id[:], self[:])
+
+	byteIdx := idx / 8
+	bitIdx := uint(idx % 8)
+
+	// Flip the bit that determines this bucket, then randomize everything
+	// after it; the leading `idx` bits must keep matching self.
+	id[byteIdx] ^= 1 << (7 - bitIdx)
+
+	tail := make([]byte, IDLength-byteIdx)
+	_, _ = rand.Read(tail)
+
+	mask := byte(0xFF) >> (bitIdx + 1)
+	id[byteIdx] = (id[byteIdx] &^ mask) | (tail[0] & mask)
+	copy(id[byteIdx+1:], tail[1:])
+
+	return id
+}
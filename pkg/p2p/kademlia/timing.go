@@ -0,0 +1,35 @@
+package kademlia
+
+import "time"
+
+// Timing holds the tunable intervals governing Kademlia maintenance
+// (liveness probes, lookups, republish, bucket refresh), so tests can shrink
+// them well below the real-network defaults.
+type Timing struct {
+	// RPCTimeout bounds every outbound RPC (find node, find value, store,
+	// ping, sign request).
+	RPCTimeout time.Duration
+	// RepublishInterval is how often locally-originated keys are re-Put to
+	// the network.
+	RepublishInterval time.Duration
+	// ExpireAfter is how long a stored value survives locally without being
+	// (re)published.
+	ExpireAfter time.Duration
+	// RefreshInterval is how often the routing table is checked for stale
+	// buckets.
+	RefreshInterval time.Duration
+	// RefreshAfter is how long a bucket may go untouched before a refresh
+	// lookup is run against it.
+	RefreshAfter time.Duration
+}
+
+// DefaultTiming returns sane real-network defaults.
+func DefaultTiming() Timing {
+	return Timing{
+		RPCTimeout:        3 * time.Second,
+		RepublishInterval: time.Hour,
+		ExpireAfter:       24 * time.Hour,
+		RefreshInterval:   5 * time.Minute,
+		RefreshAfter:      time.Hour,
+	}
+}
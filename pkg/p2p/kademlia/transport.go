@@ -0,0 +1,135 @@
+package kademlia
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"time"
+)
+
+// RPCType identifies a Kademlia wire RPC.
+type RPCType uint8
+
+const (
+	RPCPing RPCType = iota
+	RPCStore
+	RPCFindNode
+	RPCFindValue
+	RPCSignRequest
+	RPCNewState
+	RPCGossip
+)
+
+// Envelope is the wire message exchanged between peers. Payload is left as
+// raw JSON so each RPC can define its own request/response shape.
+type Envelope struct {
+	Type    RPCType
+	Sender  Contact
+	Payload json.RawMessage
+}
+
+// Transport abstracts how Envelopes are exchanged between peers, so the DHT
+// logic doesn't care whether it's running over TCP, in-process channels
+// (tests), or something else entirely.
+type Transport interface {
+	// Send delivers msg to addr and returns the peer's response.
+	Send(addr string, msg Envelope) (Envelope, error)
+
+	// Serve starts accepting connections on addr, dispatching each Envelope
+	// to handler and writing back whatever handler returns.
+	Serve(addr string, handler func(Envelope) Envelope) error
+
+	// Close stops Serve and releases any resources.
+	Close() error
+}
+
+// TCPTransport is the default Transport: length-prefixed JSON frames over
+// plain TCP. Frames are [4-byte big-endian length][JSON body].
+type TCPTransport struct {
+	DialTimeout time.Duration
+
+	listener net.Listener
+}
+
+// NewTCPTransport creates a TCPTransport with a sane default dial timeout.
+func NewTCPTransport() *TCPTransport {
+	return &TCPTransport{DialTimeout: 5 * time.Second}
+}
+
+func (t *TCPTransport) Send(addr string, msg Envelope) (Envelope, error) {
+	conn, err := net.DialTimeout("tcp", addr, t.DialTimeout)
+	if err != nil {
+		return Envelope{}, err
+	}
+	defer conn.Close()
+
+	if err := writeFrame(conn, msg); err != nil {
+		return Envelope{}, err
+	}
+	var resp Envelope
+	if err := readFrame(conn, &resp); err != nil {
+		return Envelope{}, err
+	}
+	return resp, nil
+}
+
+func (t *TCPTransport) Serve(addr string, handler func(Envelope) Envelope) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	t.listener = ln
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return // listener closed
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				var req Envelope
+				if err := readFrame(c, &req); err != nil {
+					return
+				}
+				resp := handler(req)
+				_ = writeFrame(c, resp)
+			}(conn)
+		}
+	}()
+	return nil
+}
+
+func (t *TCPTransport) Close() error {
+	if t.listener == nil {
+		return nil
+	}
+	return t.listener.Close()
+}
+
+func writeFrame(w io.Writer, msg Envelope) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var lenPrefix [4]byte
+	binary.BigEndian.PutUint32(lenPrefix[:], uint32(len(body)))
+	if _, err := w.Write(lenPrefix[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func readFrame(r io.Reader, msg *Envelope) error {
+	var lenPrefix [4]byte
+	if _, err := io.ReadFull(r, lenPrefix[:]); err != nil {
+		return err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(lenPrefix[:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return err
+	}
+	return json.Unmarshal(body, msg)
+}
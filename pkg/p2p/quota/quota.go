@@ -0,0 +1,141 @@
+// Package quota adds per-peer concurrency limits and backpressure around a
+// ports.P2PAdapter, so a single misbehaving quorum member can't flood a node
+// with sign requests (e.g. during a challenge storm) and starve everyone
+// else.
+package quota
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// ErrPeerQuotaExceeded is returned (never blocked on) once a peer has
+// exhausted one of its configured quotas.
+var ErrPeerQuotaExceeded = errors.New("quota: peer quota exceeded")
+
+// Config bounds how much concurrent work a single peer may have in flight
+// against this node.
+type Config struct {
+	// MaxConcurrentServersPerPeer caps inbound requests (OnSignRequest,
+	// OnNewState) this node will service for a single peer at once.
+	MaxConcurrentServersPerPeer int
+	// MaxConcurrentClientsPerPeer caps outbound requests this node will have
+	// in flight to a single peer at once.
+	MaxConcurrentClientsPerPeer int
+	// MaxInflightSignatureRequests caps outbound RequestSignature calls in
+	// flight to a single peer specifically.
+	MaxInflightSignatureRequests int
+	// ClientQueueTimeout bounds how long an outbound call waits for a free
+	// client-side slot before failing fast. Zero means fail immediately
+	// instead of queueing.
+	ClientQueueTimeout time.Duration
+}
+
+// Limiter enforces a Config on a per-peer basis and reports outcomes via an
+// injected ports.Metrics.
+type Limiter struct {
+	cfg     Config
+	metrics ports.Metrics
+
+	mu      sync.Mutex
+	servers map[string]int
+	clients map[string]int
+	sigs    map[string]int
+}
+
+// NewLimiter creates a Limiter. metrics may be nil, in which case counters
+// are discarded.
+func NewLimiter(cfg Config, metrics ports.Metrics) *Limiter {
+	if metrics == nil {
+		metrics = NoopMetrics{}
+	}
+	return &Limiter{
+		cfg:     cfg,
+		metrics: metrics,
+		servers: make(map[string]int),
+		clients: make(map[string]int),
+		sigs:    make(map[string]int),
+	}
+}
+
+// AdmitServer admits an inbound request from peerID, or returns
+// ErrPeerQuotaExceeded immediately: server-side quotas never block, they
+// just reject, so a slow/malicious peer can't tie up handler goroutines.
+func (l *Limiter) AdmitServer(peerID string) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	max := l.cfg.MaxConcurrentServersPerPeer
+	if max > 0 && l.servers[peerID] >= max {
+		l.record("server_rejected", peerID)
+		return nil, ErrPeerQuotaExceeded
+	}
+	l.servers[peerID]++
+	l.record("server_accepted", peerID)
+
+	return func() {
+		l.mu.Lock()
+		l.servers[peerID]--
+		l.mu.Unlock()
+	}, nil
+}
+
+// AdmitClient reserves a general outbound slot to peerID, queueing (subject
+// to Config.ClientQueueTimeout) or failing fast once the budget is
+// exhausted.
+func (l *Limiter) AdmitClient(peerID string) (release func(), err error) {
+	return l.admit(peerID, l.clients, l.cfg.MaxConcurrentClientsPerPeer, "client")
+}
+
+// AdmitSignatureRequest is the RequestSignature-specific budget, separate
+// from the general client budget since sign requests are the hot path
+// during a challenge storm.
+func (l *Limiter) AdmitSignatureRequest(peerID string) (release func(), err error) {
+	return l.admit(peerID, l.sigs, l.cfg.MaxInflightSignatureRequests, "signature")
+}
+
+func (l *Limiter) admit(peerID string, counts map[string]int, max int, label string) (func(), error) {
+	if max <= 0 {
+		return func() {}, nil
+	}
+
+	deadline := time.Now().Add(l.cfg.ClientQueueTimeout)
+	queued := false
+	for {
+		l.mu.Lock()
+		if counts[peerID] < max {
+			counts[peerID]++
+			l.mu.Unlock()
+			l.record(label+"_accepted", peerID)
+			return func() {
+				l.mu.Lock()
+				counts[peerID]--
+				l.mu.Unlock()
+			}, nil
+		}
+		l.mu.Unlock()
+
+		if l.cfg.ClientQueueTimeout <= 0 || time.Now().After(deadline) {
+			l.record(label+"_rejected", peerID)
+			return nil, ErrPeerQuotaExceeded
+		}
+		if !queued {
+			l.record(label+"_queued", peerID)
+			queued = true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (l *Limiter) record(event, peerID string) {
+	l.metrics.IncCounter("p2p_quota_"+event, map[string]string{"peer": peerID})
+}
+
+// NoopMetrics discards every counter. It's the default when no ports.Metrics
+// is supplied.
+type NoopMetrics struct{}
+
+func (NoopMetrics) IncCounter(name string, labels map[string]string) {}
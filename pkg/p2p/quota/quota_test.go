@@ -0,0 +1,119 @@
+package quota
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_AdmitServerRejectsOncePeerIsAtCapacity(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrentServersPerPeer: 1}, nil)
+
+	release, err := l.AdmitServer("peer-a")
+	if err != nil {
+		t.Fatalf("first AdmitServer: %v", err)
+	}
+	if _, err := l.AdmitServer("peer-a"); err != ErrPeerQuotaExceeded {
+		t.Fatalf("second AdmitServer = %v, want ErrPeerQuotaExceeded", err)
+	}
+
+	// A different peer has its own budget and should be unaffected.
+	if _, err := l.AdmitServer("peer-b"); err != nil {
+		t.Fatalf("AdmitServer(peer-b): %v", err)
+	}
+
+	release()
+	if _, err := l.AdmitServer("peer-a"); err != nil {
+		t.Fatalf("AdmitServer after release: %v", err)
+	}
+}
+
+func TestLimiter_ZeroMaxMeansUnbounded(t *testing.T) {
+	l := NewLimiter(Config{}, nil)
+
+	for i := 0; i < 100; i++ {
+		if _, err := l.AdmitServer("peer-a"); err != nil {
+			t.Fatalf("AdmitServer iteration %d: %v", i, err)
+		}
+	}
+}
+
+func TestLimiter_AdmitClientFailsFastWithNoQueueTimeout(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrentClientsPerPeer: 1}, nil)
+
+	if _, err := l.AdmitClient("peer-a"); err != nil {
+		t.Fatalf("first AdmitClient: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := l.AdmitClient("peer-a"); err != ErrPeerQuotaExceeded {
+		t.Fatalf("second AdmitClient = %v, want ErrPeerQuotaExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("AdmitClient with no queue timeout took %v, want near-immediate rejection", elapsed)
+	}
+}
+
+func TestLimiter_AdmitClientQueuesUntilASlotFreesUp(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrentClientsPerPeer: 1, ClientQueueTimeout: time.Second}, nil)
+
+	release, err := l.AdmitClient("peer-a")
+	if err != nil {
+		t.Fatalf("first AdmitClient: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+
+	if _, err := l.AdmitClient("peer-a"); err != nil {
+		t.Fatalf("queued AdmitClient: %v", err)
+	}
+}
+
+func TestLimiter_AdmitClientTimesOutIfNoSlotFreesUp(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrentClientsPerPeer: 1, ClientQueueTimeout: 30 * time.Millisecond}, nil)
+
+	if _, err := l.AdmitClient("peer-a"); err != nil {
+		t.Fatalf("first AdmitClient: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := l.AdmitClient("peer-a"); err != ErrPeerQuotaExceeded {
+		t.Fatalf("second AdmitClient = %v, want ErrPeerQuotaExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("AdmitClient returned after %v, want it to have waited out the queue timeout", elapsed)
+	}
+}
+
+func TestLimiter_SignatureRequestBudgetIsSeparateFromClientBudget(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrentClientsPerPeer: 1, MaxInflightSignatureRequests: 1}, nil)
+
+	if _, err := l.AdmitClient("peer-a"); err != nil {
+		t.Fatalf("AdmitClient: %v", err)
+	}
+	if _, err := l.AdmitSignatureRequest("peer-a"); err != nil {
+		t.Fatalf("AdmitSignatureRequest should have its own budget, got: %v", err)
+	}
+}
+
+func TestLimiter_ReleaseIsPerPeer(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrentServersPerPeer: 1}, nil)
+
+	releaseA, err := l.AdmitServer("peer-a")
+	if err != nil {
+		t.Fatalf("AdmitServer(peer-a): %v", err)
+	}
+	if _, err := l.AdmitServer("peer-b"); err != nil {
+		t.Fatalf("AdmitServer(peer-b): %v", err)
+	}
+
+	releaseA()
+	if _, err := l.AdmitServer("peer-a"); err != nil {
+		t.Fatalf("AdmitServer(peer-a) after its own release: %v", err)
+	}
+	if _, err := l.AdmitServer("peer-b"); err != ErrPeerQuotaExceeded {
+		t.Fatalf("AdmitServer(peer-b) = %v, want still exhausted: releasing peer-a must not free peer-b's budget", err)
+	}
+}
@@ -13,6 +13,13 @@ const (
 	EventWithdrawalRequested EventType = "WithdrawalRequested"
 	EventChallenged          EventType = "Challenged"
 	EventWithdrawn           EventType = "Withdrawn"
+
+	// Node registry events, emitted alongside the Registry writes below so
+	// consumers (e.g. registry/cache) can invalidate cached node data
+	// without polling GetNodes.
+	EventNodeRegistered   EventType = "NodeRegistered"
+	EventNodeUnregistered EventType = "NodeUnregistered"
+	EventNodeUpdated      EventType = "NodeUpdated"
 )
 
 // BlockchainEvent generic event structure
@@ -35,6 +42,9 @@ type RequestPayload struct {
 type ChallengePayload struct {
 	State      *core.State
 	Challenger string
+	// Bounty is how much of State.Wallet's on-chain balance Challenge moved
+	// to Challenger as a reward, zero if none was requested.
+	Bounty *big.Int
 }
 
 type WithdrawnPayload struct {
@@ -42,18 +52,50 @@ type WithdrawnPayload struct {
 	Amount *big.Int
 }
 
+// NodeRegisteredPayload accompanies EventNodeRegistered.
+type NodeRegisteredPayload struct {
+	Node NodeInfo
+}
+
+// NodeUnregisteredPayload accompanies EventNodeUnregistered. Only the ID is
+// guaranteed to still be meaningful once a node has left the registry.
+type NodeUnregisteredPayload struct {
+	ID [32]byte
+}
+
+// NodeUpdatedPayload accompanies EventNodeUpdated, carrying the node's new
+// address.
+type NodeUpdatedPayload struct {
+	Node NodeInfo
+}
+
 // BlockchainAdapter defines interaction with the On-Chain world
 type BlockchainAdapter interface {
 	// Methods called by Users/Nodes
 	Deposit(wallet, token string, amount *big.Int) error
 	RequestWithdrawal(state *core.State) error
-	Challenge(state *core.State, challengerID string) error
+	// Challenge defeats a stale withdrawal request with a newer quorum-signed
+	// state. bounty, if positive, is carved out of state.Wallet's on-chain
+	// balance and credited to challengerID as a reward for catching the
+	// fraud, capped at whatever balance is actually there to slash.
+	Challenge(state *core.State, challengerID string, bounty *big.Int) error
 	Withdraw(wallet string) error
 
 	// Event Subscription
 	Subscribe() <-chan BlockchainEvent
 }
 
+// GossipMessage is a flooded, best-effort broadcast to every known peer,
+// used for network-wide announcements (e.g. pkg/routing's channel-liquidity
+// ads) that aren't scoped to one wallet's quorum the way PublishState/
+// OnNewState are. Payload is JSON-encoded so it survives a real wire hop
+// (see kademlia.Adapter.Gossip); the type registering Type is responsible
+// for decoding it.
+type GossipMessage struct {
+	Type    string
+	Payload []byte
+}
+
 // P2PAdapter defines interaction between nodes
 type P2PAdapter interface {
 	// Publish a new state to the network
@@ -67,10 +109,15 @@ type P2PAdapter interface {
 
 	// Register this node to the network
 	RegisterNode(nodeID string, handler NodeHandler)
+
+	// Gossip floods msg to known peers; delivery is best-effort and a peer
+	// may see the same message more than once.
+	Gossip(msg GossipMessage) error
 }
 
 // NodeHandler defines callbacks for incoming P2P requests
 type NodeHandler interface {
 	OnSignRequest(state *core.State) ([]byte, error)
 	OnNewState(state *core.State)
+	OnGossip(msg GossipMessage)
 }
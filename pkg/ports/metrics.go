@@ -0,0 +1,9 @@
+package ports
+
+// Metrics is an injectable sink for operational counters, so adapters and
+// middleware (e.g. pkg/p2p/quota) don't take a hard dependency on any
+// specific metrics library. A Prometheus-backed implementation would
+// typically map IncCounter to a *prometheus.CounterVec keyed by labels.
+type Metrics interface {
+	IncCounter(name string, labels map[string]string)
+}
@@ -24,10 +24,16 @@ type Registry interface {
     // Reads
     GetManifest(ctx context.Context) (*Manifest, error)
     GetNodes(ctx context.Context, offset, limit uint64) ([]NodeInfo, error)
-    
+
     // Writes (Node Operator)
     Register(ctx context.Context, nodeID [32]byte, domain string, port uint16, stake *big.Int) error
     UpdateNode(ctx context.Context, domain string, port uint16) error
     Unregister(ctx context.Context) error
     Withdraw(ctx context.Context) error
+
+    // Subscribe streams EventNodeRegistered/EventNodeUnregistered/EventNodeUpdated
+    // as the registry's write methods above are called, the same BlockchainEvent
+    // feed BlockchainAdapter.Subscribe uses for its own event types, so
+    // registry/cache.Cache can invalidate without polling GetNodes.
+    Subscribe() <-chan BlockchainEvent
 }
\ No newline at end of file
@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// pageKey identifies one GetNodes(offset, limit) page.
+type pageKey struct {
+	Offset, Limit uint64
+}
+
+// listID identifies which of arcPageCache's four lists a page currently
+// lives in.
+type listID int
+
+const (
+	listT1 listID = iota
+	listT2
+	listB1
+	listB2
+)
+
+// arcPageCache is an Adaptive Replacement Cache (the standard T1/T2/B1/B2
+// four-list algorithm; see pkg/statestore.ARCCache for the same construction
+// over content-addressed blocks) over paginated GetNodes snapshots. Unlike
+// ARCCache it doesn't wrap a fetcher itself: Cache.GetNodes does the
+// underlying registry call on a miss and Puts the result.
+type arcPageCache struct {
+	capacity int
+
+	mu sync.Mutex
+	p  int // target size for T1
+
+	t1, t2, b1, b2 *list.List
+	elems          map[pageKey]*list.Element
+	loc            map[pageKey]listID
+	values         map[pageKey][]ports.NodeInfo
+}
+
+func newARCPageCache(capacity int) *arcPageCache {
+	return &arcPageCache{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		elems:    make(map[pageKey]*list.Element),
+		loc:      make(map[pageKey]listID),
+		values:   make(map[pageKey][]ports.NodeInfo),
+	}
+}
+
+// Get returns a cached page, if any. The slice is shared with the cache and
+// must not be mutated by the caller.
+func (c *arcPageCache) Get(key pageKey) ([]ports.NodeInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nodes, ok := c.values[key]
+	if !ok {
+		return nil, false
+	}
+	c.promote(key)
+	return nodes, true
+}
+
+// Put records nodes as the current snapshot for key.
+func (c *arcPageCache) Put(key pageKey, nodes []ports.NodeInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordAccess(key, nodes)
+}
+
+// Purge drops every cached page. Used when the underlying node set changes,
+// since a Register/Unregister shifts every page's contents.
+func (c *arcPageCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t1, c.t2, c.b1, c.b2 = list.New(), list.New(), list.New(), list.New()
+	c.elems = make(map[pageKey]*list.Element)
+	c.loc = make(map[pageKey]listID)
+	c.values = make(map[pageKey][]ports.NodeInfo)
+	c.p = 0
+}
+
+func (c *arcPageCache) promote(key pageKey) {
+	elem, ok := c.elems[key]
+	if !ok {
+		return
+	}
+	switch c.loc[key] {
+	case listT1:
+		c.t1.Remove(elem)
+	case listT2:
+		c.t2.Remove(elem)
+	default:
+		return
+	}
+	c.elems[key] = c.t2.PushFront(key)
+	c.loc[key] = listT2
+}
+
+func (c *arcPageCache) recordAccess(key pageKey, nodes []ports.NodeInfo) {
+	if loc, ok := c.loc[key]; ok {
+		switch loc {
+		case listT1, listT2:
+			c.values[key] = nodes
+			c.promote(key)
+			return
+		case listB1:
+			b1Len, b2Len := c.b1.Len(), c.b2.Len()
+			delta := 1
+			if b1Len > 0 && b2Len > b1Len {
+				delta = b2Len / b1Len
+			}
+			c.p = minInt(c.capacity, c.p+delta)
+			c.replace(false)
+			c.moveToT2(key, c.b1, nodes)
+			return
+		case listB2:
+			b1Len, b2Len := c.b1.Len(), c.b2.Len()
+			delta := 1
+			if b2Len > 0 && b1Len > b2Len {
+				delta = b1Len / b2Len
+			}
+			c.p = maxInt(0, c.p-delta)
+			c.replace(true)
+			c.moveToT2(key, c.b2, nodes)
+			return
+		}
+	}
+
+	// Brand new key.
+	l1Len := c.t1.Len() + c.b1.Len()
+	if l1Len == c.capacity {
+		if c.t1.Len() < c.capacity {
+			c.evictGhost(c.b1)
+			c.replace(false)
+		} else {
+			c.evictCacheEntry(c.t1)
+		}
+	} else if l1Len < c.capacity && (l1Len+c.t2.Len()+c.b2.Len()) >= c.capacity {
+		if l1Len+c.t2.Len()+c.b1.Len()+c.b2.Len() >= 2*c.capacity {
+			c.evictGhost(c.b2)
+		}
+		c.replace(false)
+	}
+	c.elems[key] = c.t1.PushFront(key)
+	c.loc[key] = listT1
+	c.values[key] = nodes
+}
+
+func (c *arcPageCache) moveToT2(key pageKey, ghost *list.List, nodes []ports.NodeInfo) {
+	ghost.Remove(c.elems[key])
+	c.elems[key] = c.t2.PushFront(key)
+	c.loc[key] = listT2
+	c.values[key] = nodes
+}
+
+func (c *arcPageCache) replace(inB2 bool) {
+	if c.t1.Len() > 0 && (c.t1.Len() > c.p || (inB2 && c.t1.Len() == c.p)) {
+		c.evictCacheEntry(c.t1)
+	} else if c.t2.Len() > 0 {
+		c.evictCacheEntryTo(c.t2, c.b2, listB2)
+	} else if c.t1.Len() > 0 {
+		c.evictCacheEntry(c.t1)
+	}
+}
+
+func (c *arcPageCache) evictCacheEntry(l *list.List) {
+	c.evictCacheEntryTo(l, c.b1, listB1)
+}
+
+func (c *arcPageCache) evictCacheEntryTo(l *list.List, ghost *list.List, ghostID listID) {
+	back := l.Back()
+	if back == nil {
+		return
+	}
+	key := back.Value.(pageKey)
+	l.Remove(back)
+	delete(c.values, key)
+	c.elems[key] = ghost.PushFront(key)
+	c.loc[key] = ghostID
+}
+
+func (c *arcPageCache) evictGhost(l *list.List) {
+	back := l.Back()
+	if back == nil {
+		return
+	}
+	key := back.Value.(pageKey)
+	l.Remove(back)
+	delete(c.elems, key)
+	delete(c.loc, key)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
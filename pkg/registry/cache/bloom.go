@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// bloomFilter is a small self-contained Bloom filter using double hashing
+// (Kirsch-Mitzenmacher) to derive k index positions from a single SHA-256
+// digest, avoiding the need for k independent hash functions. See
+// pkg/statestore's bloomFilter for the same construction over content
+// hashes.
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+}
+
+func newBloomFilter(expectedItems uint64, falsePositiveRate float64) *bloomFilter {
+	if expectedItems == 0 {
+		expectedItems = 1
+	}
+	m := optimalBits(expectedItems, falsePositiveRate)
+	k := optimalHashes(m, expectedItems)
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func optimalBits(n uint64, p float64) uint64 {
+	m := -1 * float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)
+	if m < 1 {
+		m = 1
+	}
+	return uint64(math.Ceil(m))
+}
+
+func optimalHashes(m, n uint64) uint64 {
+	k := math.Round(float64(m) / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return uint64(k)
+}
+
+func (f *bloomFilter) positions(key [32]byte) (h1, h2 uint64) {
+	sum := sha256.Sum256(key[:])
+	return binary.BigEndian.Uint64(sum[0:8]), binary.BigEndian.Uint64(sum[8:16])
+}
+
+func (f *bloomFilter) Add(key [32]byte) {
+	h1, h2 := f.positions(key)
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+// Test reports whether key might be present (false positives possible;
+// false negatives are not).
+func (f *bloomFilter) Test(key [32]byte) bool {
+	h1, h2 := f.positions(key)
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,250 @@
+// Package cache wraps a ports.Registry with an ARC-cached, paginated
+// snapshot of the node set plus a Bloom filter for fast negative Contains
+// checks, so callers like the Kademlia adapter don't have to re-scan the
+// on-chain registry (or MockRegistry's mutex-guarded slice) on every lookup.
+//
+// The cache is invalidated by subscribing to the wrapped ports.Registry's own
+// event stream for EventNodeRegistered/EventNodeUnregistered/EventNodeUpdated,
+// rather than by polling GetNodes.
+package cache
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// ChangeType classifies a NodeChangeEvent.
+type ChangeType int
+
+const (
+	NodeAdded ChangeType = iota
+	NodeRemoved
+	NodeUpdated
+)
+
+// NodeChangeEvent is delivered to WatchNodes subscribers as the node set
+// changes. For NodeRemoved, only Node.ID is populated.
+type NodeChangeEvent struct {
+	Type ChangeType
+	Node ports.NodeInfo
+}
+
+// Cache wraps a ports.Registry, adding an ARC page cache and a Bloom filter
+// over known node IDs. It implements ports.Registry itself, so it can be
+// used as a drop-in replacement anywhere a Registry is expected.
+type Cache struct {
+	registry ports.Registry
+
+	pages *arcPageCache
+	bloom *bloomFilter
+
+	mu       sync.Mutex
+	removed  map[[32]byte]bool // unregistered since the last bloom rebuild; bloom bits can't be cleared
+	watchers []chan NodeChangeEvent
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// New wraps registry with an ARC page cache (pageCapacity pages) and a Bloom
+// filter sized for expectedNodes at falsePositiveRate, and subscribes to
+// registry's own event stream to invalidate itself as node events arrive.
+func New(registry ports.Registry, pageCapacity int, expectedNodes uint64, falsePositiveRate float64) *Cache {
+	c := &Cache{
+		registry: registry,
+		pages:    newARCPageCache(pageCapacity),
+		bloom:    newBloomFilter(expectedNodes, falsePositiveRate),
+		removed:  make(map[[32]byte]bool),
+		stopCh:   make(chan struct{}),
+	}
+	go c.watchRegistry(registry.Subscribe())
+	return c
+}
+
+// Close stops the registry subscription loop and closes every outstanding
+// WatchNodes channel.
+func (c *Cache) Close() {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+
+	c.mu.Lock()
+	watchers := c.watchers
+	c.watchers = nil
+	c.mu.Unlock()
+
+	for _, w := range watchers {
+		close(w)
+	}
+}
+
+// --- ports.Registry ---
+
+// GetManifest is not paginated data and isn't cached; it's cheap and rarely
+// called compared to GetNodes.
+func (c *Cache) GetManifest(ctx context.Context) (*ports.Manifest, error) {
+	return c.registry.GetManifest(ctx)
+}
+
+// GetNodes serves offset/limit pages out of the ARC cache, falling back to
+// the underlying registry on a miss.
+func (c *Cache) GetNodes(ctx context.Context, offset, limit uint64) ([]ports.NodeInfo, error) {
+	key := pageKey{Offset: offset, Limit: limit}
+	if nodes, ok := c.pages.Get(key); ok {
+		return nodes, nil
+	}
+
+	nodes, err := c.registry.GetNodes(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	c.pages.Put(key, nodes)
+	c.learn(nodes)
+	return nodes, nil
+}
+
+// Contains reports whether id is (probably) a known registered node, without
+// touching the underlying registry: a false return is always correct, a true
+// return can be a false positive (standard Bloom filter semantics) or a
+// stale positive for a node unregistered since the last full learn.
+func (c *Cache) Contains(id [32]byte) bool {
+	c.mu.Lock()
+	removed := c.removed[id]
+	c.mu.Unlock()
+	if removed {
+		return false
+	}
+	return c.bloom.Test(id)
+}
+
+// Writes pass straight through: mutations happen on-chain, and the resulting
+// event is what drives cache invalidation, not the write call itself.
+
+func (c *Cache) Register(ctx context.Context, nodeID [32]byte, domain string, port uint16, stake *big.Int) error {
+	return c.registry.Register(ctx, nodeID, domain, port, stake)
+}
+
+func (c *Cache) UpdateNode(ctx context.Context, domain string, port uint16) error {
+	return c.registry.UpdateNode(ctx, domain, port)
+}
+
+func (c *Cache) Unregister(ctx context.Context) error {
+	return c.registry.Unregister(ctx)
+}
+
+func (c *Cache) Withdraw(ctx context.Context) error {
+	return c.registry.Withdraw(ctx)
+}
+
+// Subscribe passes through to the wrapped registry, so a Cache can itself be
+// wrapped by another Cache (or otherwise treated as any other ports.Registry)
+// without losing the event feed. Cache's own invalidation already consumes
+// this same feed internally via watchRegistry.
+func (c *Cache) Subscribe() <-chan ports.BlockchainEvent {
+	return c.registry.Subscribe()
+}
+
+// --- change feed ---
+
+// WatchNodes returns a channel of node add/remove/update events derived from
+// the registry's own event stream, so consumers (e.g. the Kademlia routing
+// table) can react without polling GetNodes. The channel is closed when ctx
+// is done or the Cache is Closed.
+func (c *Cache) WatchNodes(ctx context.Context) <-chan NodeChangeEvent {
+	ch := make(chan NodeChangeEvent, 32)
+
+	c.mu.Lock()
+	c.watchers = append(c.watchers, ch)
+	c.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.stopCh:
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, w := range c.watchers {
+			if w == ch {
+				c.watchers = append(c.watchers[:i], c.watchers[i+1:]...)
+				close(ch)
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// --- invalidation ---
+
+func (c *Cache) watchRegistry(events <-chan ports.BlockchainEvent) {
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			c.handleRegistryEvent(ev)
+		}
+	}
+}
+
+func (c *Cache) handleRegistryEvent(ev ports.BlockchainEvent) {
+	switch ev.Type {
+	case ports.EventNodeRegistered:
+		p, ok := ev.Payload.(ports.NodeRegisteredPayload)
+		if !ok {
+			return
+		}
+		c.pages.Purge()
+		c.learn([]ports.NodeInfo{p.Node})
+		c.broadcast(NodeChangeEvent{Type: NodeAdded, Node: p.Node})
+
+	case ports.EventNodeUnregistered:
+		p, ok := ev.Payload.(ports.NodeUnregisteredPayload)
+		if !ok {
+			return
+		}
+		c.pages.Purge()
+		c.mu.Lock()
+		c.removed[p.ID] = true
+		c.mu.Unlock()
+		c.broadcast(NodeChangeEvent{Type: NodeRemoved, Node: ports.NodeInfo{ID: p.ID}})
+
+	case ports.EventNodeUpdated:
+		p, ok := ev.Payload.(ports.NodeUpdatedPayload)
+		if !ok {
+			return
+		}
+		c.pages.Purge()
+		c.learn([]ports.NodeInfo{p.Node})
+		c.broadcast(NodeChangeEvent{Type: NodeUpdated, Node: p.Node})
+	}
+}
+
+// learn records nodes as known-present: it sets their Bloom bits and clears
+// any stale "removed" mark (covers re-registration).
+func (c *Cache) learn(nodes []ports.NodeInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, n := range nodes {
+		c.bloom.Add(n.ID)
+		delete(c.removed, n.ID)
+	}
+}
+
+func (c *Cache) broadcast(ev NodeChangeEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.watchers {
+		select {
+		case w <- ev:
+		default:
+			// Slow subscriber; drop rather than block chain event delivery.
+		}
+	}
+}
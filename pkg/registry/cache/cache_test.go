@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mod/clearnet/pkg/adapters/mockregistry"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+func TestCache_GetNodesServesFromCacheUntilInvalidated(t *testing.T) {
+	reg := mockregistry.New()
+	c := New(reg, 16, 1000, 0.01)
+	defer c.Close()
+
+	var id1 [32]byte
+	id1[0] = 1
+	if err := reg.Register(context.Background(), id1, "node-1.example", 9000, big.NewInt(250000)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	nodes, err := c.GetNodes(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatalf("GetNodes: %v", err)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(nodes))
+	}
+
+	// Registering a second node directly against reg (bypassing the cache's
+	// passthrough Register) must still show up: the event stream, not the
+	// write path, is what invalidates the page cache.
+	var id2 [32]byte
+	id2[0] = 2
+	if err := reg.Register(context.Background(), id2, "node-2.example", 9001, big.NewInt(250000)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		nodes, err = c.GetNodes(context.Background(), 0, 10)
+		if err != nil {
+			t.Fatalf("GetNodes: %v", err)
+		}
+		if len(nodes) == 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("page cache was never invalidated; still see %d node(s)", len(nodes))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestCache_WatchNodesReceivesNodeAdded(t *testing.T) {
+	reg := mockregistry.New()
+	c := New(reg, 16, 1000, 0.01)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := c.WatchNodes(ctx)
+
+	var id [32]byte
+	id[0] = 7
+	if err := reg.Register(context.Background(), id, "node-7.example", 9007, big.NewInt(250000)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Type != NodeAdded {
+			t.Fatalf("expected NodeAdded, got %v", ev.Type)
+		}
+		if ev.Node.ID != id {
+			t.Fatalf("expected node ID %x, got %x", id, ev.Node.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for NodeAdded event")
+	}
+}
+
+func TestCache_ContainsReflectsRemoval(t *testing.T) {
+	reg := mockregistry.New()
+	c := New(reg, 16, 1000, 0.01)
+	defer c.Close()
+
+	var id [32]byte
+	id[0] = 9
+	if err := reg.Register(context.Background(), id, "node-9.example", 9009, big.NewInt(250000)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := c.GetNodes(context.Background(), 0, 10); err != nil {
+		t.Fatalf("GetNodes: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for !c.Contains(id) {
+		select {
+		case <-deadline:
+			t.Fatal("Contains never became true after registration")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	c.handleRegistryEvent(ports.BlockchainEvent{
+		Type:    ports.EventNodeUnregistered,
+		Payload: ports.NodeUnregisteredPayload{ID: id},
+	})
+	if c.Contains(id) {
+		t.Fatal("expected Contains to report false immediately after an unregister event")
+	}
+}
@@ -0,0 +1,185 @@
+// Package routing maintains a channel graph for multi-hop HTLC payments: who
+// (which node) holds state for which wallet, and how much liquidity it has
+// advertised, gossiped network-wide over a ports.P2PAdapter rather than
+// scoped to a single wallet's quorum the way PublishState/GetLatestState are.
+//
+// A "channel" here is a node simultaneously servicing two wallets: a payment
+// can hop from wallet A to wallet B through any node that advertises
+// liquidity for both, the same way a Lightning payment hops through any node
+// that has a channel open on both sides.
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// gossipType identifies routing's messages on the shared ports.GossipMessage
+// bus, so HandleGossip can ignore gossip meant for other packages.
+const gossipType = "routing.channel_ad"
+
+// ErrNoRoute is returned by FindRoute when no path with enough liquidity
+// connects from and to.
+var ErrNoRoute = errors.New("routing: no route with sufficient liquidity")
+
+// ChannelAd advertises that NodeID holds state for Wallet/Token with
+// Liquidity available to route through (State.AvailableBalance at the time
+// of advertising).
+type ChannelAd struct {
+	NodeID    string
+	Wallet    string
+	Token     string
+	Liquidity *big.Int
+}
+
+// Route is a path of wallets connected by the nodes bridging each hop, along
+// with the most this path can currently carry (the minimum liquidity seen
+// across the hops).
+type Route struct {
+	Wallets   []string // payer wallet .. payee wallet, inclusive
+	Nodes     []string // Nodes[i] bridges Wallets[i] and Wallets[i+1]
+	MaxAmount *big.Int
+}
+
+// Graph is a node's local view of the network's channel graph, built purely
+// from gossiped ChannelAds: there is no authoritative source, so two nodes
+// may briefly disagree until gossip converges.
+type Graph struct {
+	self string
+	p2p  ports.P2PAdapter
+
+	mu         sync.RWMutex
+	ads        map[string]map[string]ChannelAd // wallet -> nodeID -> ad
+	nodeWallet map[string]map[string]bool      // nodeID -> set of wallets it serves
+}
+
+// NewGraph creates a Graph that gossips its own advertisements (via Advertise)
+// over p2p as selfID.
+func NewGraph(selfID string, p2p ports.P2PAdapter) *Graph {
+	return &Graph{
+		self:       selfID,
+		p2p:        p2p,
+		ads:        make(map[string]map[string]ChannelAd),
+		nodeWallet: make(map[string]map[string]bool),
+	}
+}
+
+// Advertise records this node's own liquidity for wallet/token and floods it
+// to the rest of the network. Call it again whenever liquidity changes
+// materially (e.g. after OnNewState updates the node's local store).
+func (g *Graph) Advertise(wallet, token string, liquidity *big.Int) error {
+	ad := ChannelAd{NodeID: g.self, Wallet: wallet, Token: token, Liquidity: liquidity}
+	g.learn(ad)
+
+	payload, err := json.Marshal(ad)
+	if err != nil {
+		return fmt.Errorf("routing: marshal channel ad: %w", err)
+	}
+	return g.p2p.Gossip(ports.GossipMessage{Type: gossipType, Payload: payload})
+}
+
+// HandleGossip implements the routing side of node.Node's OnGossip: it
+// ignores any message not of gossipType, so node.Node can forward every
+// GossipMessage it receives here unconditionally.
+func (g *Graph) HandleGossip(msg ports.GossipMessage) {
+	if msg.Type != gossipType {
+		return
+	}
+	var ad ChannelAd
+	if err := json.Unmarshal(msg.Payload, &ad); err != nil {
+		return
+	}
+	g.learn(ad)
+}
+
+func (g *Graph) learn(ad ChannelAd) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.ads[ad.Wallet] == nil {
+		g.ads[ad.Wallet] = make(map[string]ChannelAd)
+	}
+	g.ads[ad.Wallet][ad.NodeID] = ad
+
+	if g.nodeWallet[ad.NodeID] == nil {
+		g.nodeWallet[ad.NodeID] = make(map[string]bool)
+	}
+	g.nodeWallet[ad.NodeID][ad.Wallet] = true
+}
+
+// routeStep is one node in FindRoute's BFS frontier.
+type routeStep struct {
+	wallet string
+	via    string // node bridging prev wallet to this one
+	prev   *routeStep
+	max    *big.Int
+}
+
+// FindRoute does a breadth-first search over the wallet graph (two wallets
+// are adjacent if some node has advertised liquidity for both) for the
+// shortest path from `from` to `to` able to carry at least amount, the same
+// way ports.P2PAdapter.GetLatestState's pointer-key lookup stands in for a
+// real DHT query: a local best-effort view, not a guarantee.
+func (g *Graph) FindRoute(from, to string, amount *big.Int) (*Route, error) {
+	if from == to {
+		return nil, errors.New("routing: from and to wallets are the same")
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	visited := map[string]bool{from: true}
+	queue := []*routeStep{{wallet: from, max: nil}}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for nodeID := range g.ads[cur.wallet] {
+			for wallet := range g.nodeWallet[nodeID] {
+				if wallet == cur.wallet || visited[wallet] {
+					continue
+				}
+				hopLiquidity := g.ads[cur.wallet][nodeID].Liquidity
+				if hopLiquidity == nil || hopLiquidity.Cmp(amount) < 0 {
+					continue
+				}
+
+				next := &routeStep{wallet: wallet, via: nodeID, prev: cur, max: minBig(cur.max, hopLiquidity)}
+				if wallet == to {
+					return toRoute(next), nil
+				}
+				visited[wallet] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return nil, ErrNoRoute
+}
+
+func toRoute(last *routeStep) *Route {
+	var wallets, nodes []string
+	for s := last; s != nil; s = s.prev {
+		wallets = append([]string{s.wallet}, wallets...)
+		if s.via != "" {
+			nodes = append([]string{s.via}, nodes...)
+		}
+	}
+	return &Route{Wallets: wallets, Nodes: nodes, MaxAmount: last.max}
+}
+
+func minBig(a, b *big.Int) *big.Int {
+	if a == nil {
+		return new(big.Int).Set(b)
+	}
+	if a.Cmp(b) <= 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
@@ -0,0 +1,61 @@
+package routing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mod/clearnet/pkg/adapters/mockp2p"
+)
+
+// TestFindRoute_ChecksPayerSideLiquidity pins down which side of a hop's
+// advertised liquidity FindRoute must consult: the payer wallet stepping
+// into the hop, since that's the side LockRoute actually debits via
+// current.AddHTLC. A node that only advertises liquidity for the payee
+// side of a hop must not make that hop routable.
+func TestFindRoute_ChecksPayerSideLiquidity(t *testing.T) {
+	p2p := mockp2p.NewMockP2P()
+
+	g := NewGraph("relay-1", p2p)
+	g.learn(ChannelAd{NodeID: "relay-1", Wallet: "wallet-a", Token: "token-usdt", Liquidity: big.NewInt(5)})
+	g.learn(ChannelAd{NodeID: "relay-1", Wallet: "wallet-b", Token: "token-usdt", Liquidity: big.NewInt(50)})
+
+	if _, err := g.FindRoute("wallet-a", "wallet-b", big.NewInt(30)); err != ErrNoRoute {
+		t.Fatalf("FindRoute: expected ErrNoRoute when the payer side (wallet-a) hasn't advertised enough liquidity, got %v", err)
+	}
+}
+
+func TestFindRoute_FindsMultiHopPath(t *testing.T) {
+	p2p := mockp2p.NewMockP2P()
+
+	g := NewGraph("relay-1", p2p)
+	g.learn(ChannelAd{NodeID: "relay-1", Wallet: "wallet-a", Token: "token-usdt", Liquidity: big.NewInt(50)})
+	g.learn(ChannelAd{NodeID: "relay-1", Wallet: "wallet-b", Token: "token-usdt", Liquidity: big.NewInt(50)})
+	g.learn(ChannelAd{NodeID: "relay-2", Wallet: "wallet-b", Token: "token-usdt", Liquidity: big.NewInt(50)})
+	g.learn(ChannelAd{NodeID: "relay-2", Wallet: "wallet-c", Token: "token-usdt", Liquidity: big.NewInt(50)})
+
+	route, err := g.FindRoute("wallet-a", "wallet-c", big.NewInt(30))
+	if err != nil {
+		t.Fatalf("FindRoute: %v", err)
+	}
+	if got, want := route.Wallets, []string{"wallet-a", "wallet-b", "wallet-c"}; !equalStrings(got, want) {
+		t.Fatalf("route wallets = %v, want %v", got, want)
+	}
+	if got, want := route.Nodes, []string{"relay-1", "relay-2"}; !equalStrings(got, want) {
+		t.Fatalf("route nodes = %v, want %v", got, want)
+	}
+	if route.MaxAmount.Cmp(big.NewInt(50)) != 0 {
+		t.Fatalf("route max amount = %s, want 50", route.MaxAmount)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package routing
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// LockRoute carves amount out of every wallet along route except the last
+// (route.Wallets[len-1], the payee, never pays anyone further) into a
+// matching HTLC keyed by hash, one per hop, mirroring how a Lightning
+// payment locks funds hop by hop before the preimage ever moves. Expiry is
+// staggered with a one-version delta per hop (baseExpiry for the first hop,
+// decreasing moving toward the payee) so that, per validateTransition's
+// expiry-ordering check, an upstream hop can always safely time out before
+// the hop it funded does.
+//
+// It does not attempt to credit the payee's Balance: State has no concept of
+// an incoming credit distinct from a deposit, so the value actually reaching
+// Wallets[len-1] is outside what this ledger model can express yet. What
+// LockRoute gives real, signed, on-the-record custody of is that every
+// intermediate wallet's funds are provably reserved against the same hash
+// until SettleRoute or a timeout releases them.
+func (g *Graph) LockRoute(route *Route, hash string, amount *big.Int, baseExpiry uint64) ([]*core.State, error) {
+	if len(route.Wallets) < 2 {
+		return nil, fmt.Errorf("routing: route needs at least a payer and a payee, got %d wallets", len(route.Wallets))
+	}
+
+	locked := make([]*core.State, 0, len(route.Nodes))
+	for i, payer := range route.Wallets[:len(route.Wallets)-1] {
+		payee := route.Wallets[i+1]
+
+		current, err := g.p2p.GetLatestState(payer)
+		if err != nil {
+			return locked, fmt.Errorf("routing: lock hop %s->%s: %w", payer, payee, err)
+		}
+
+		next, err := current.AddHTLC(core.HTLC{
+			Hash:   hash,
+			Amount: amount,
+			Expiry: baseExpiry - uint64(i),
+			Payer:  payer,
+			Payee:  payee,
+		})
+		if err != nil {
+			return locked, fmt.Errorf("routing: lock hop %s->%s: %w", payer, payee, err)
+		}
+
+		if err := g.signAndPublish(next); err != nil {
+			return locked, fmt.Errorf("routing: lock hop %s->%s: %w", payer, payee, err)
+		}
+		locked = append(locked, next)
+	}
+	return locked, nil
+}
+
+// SettleRoute reveals preimage to every hop LockRoute reserved, releasing
+// each wallet's own reservation in payee-to-payer order: the payee's hop
+// settles first so that, were a crash to interrupt the walk partway, every
+// wallet still holding a pending HTLC has strictly more time left on its
+// expiry than the hop that already settled, never less.
+func (g *Graph) SettleRoute(route *Route, preimage string) error {
+	for i := len(route.Wallets) - 2; i >= 0; i-- {
+		payer := route.Wallets[i]
+
+		current, err := g.p2p.GetLatestState(payer)
+		if err != nil {
+			return fmt.Errorf("routing: settle hop at %s: %w", payer, err)
+		}
+
+		next, err := current.SettleHTLC(preimage)
+		if err != nil {
+			return fmt.Errorf("routing: settle hop at %s: %w", payer, err)
+		}
+
+		if err := g.signAndPublish(next); err != nil {
+			return fmt.Errorf("routing: settle hop at %s: %w", payer, err)
+		}
+	}
+	return nil
+}
+
+// signAndPublish collects a signature from every participant in state
+// (state.Hash() doesn't change between requests, so every signer is asked
+// to sign the same thing) and publishes the result, the same round-robin
+// RequestSignature pattern cmd/simdemo's signQuorum uses for a single state.
+func (g *Graph) signAndPublish(state *core.State) error {
+	for _, nodeID := range state.Participants {
+		sig, err := g.p2p.RequestSignature(nodeID, state)
+		if err != nil {
+			return fmt.Errorf("request signature from %s: %w", nodeID, err)
+		}
+		state.Sigs = append(state.Sigs, sig)
+	}
+	return g.p2p.PublishState(state)
+}
@@ -0,0 +1,130 @@
+package routing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mod/clearnet/pkg/adapters/mockp2p"
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// signAllHandler is a minimal ports.NodeHandler that signs whatever it's
+// asked to, standing in for a node.Node that already trusts the caller:
+// these tests exercise LockRoute/SettleRoute driving AddHTLC/SettleHTLC
+// correctly, not node.Node's own validation (see pkg/node's tests for that).
+type signAllHandler struct{ id string }
+
+func (h *signAllHandler) OnSignRequest(state *core.State) ([]byte, error) {
+	return []byte("sig:" + h.id + ":" + state.Hash()), nil
+}
+func (h *signAllHandler) OnNewState(state *core.State)     {}
+func (h *signAllHandler) OnGossip(msg ports.GossipMessage) {}
+
+func TestLockRouteAndSettleRoute(t *testing.T) {
+	p2p := mockp2p.NewMockP2P()
+	p2p.RegisterNode("relay-1", &signAllHandler{id: "relay-1"})
+	p2p.RegisterNode("relay-2", &signAllHandler{id: "relay-2"})
+
+	seed := func(wallet string, balance int64) {
+		if err := p2p.PublishState(&core.State{
+			Wallet:       wallet,
+			Token:        "token-usdt",
+			Version:      1,
+			Balance:      big.NewInt(balance),
+			Participants: []string{"relay-1"},
+		}); err != nil {
+			t.Fatalf("seed %s: %v", wallet, err)
+		}
+	}
+	seed("wallet-a", 100)
+	seed("wallet-b", 100)
+	seed("wallet-c", 100)
+
+	route := &Route{
+		Wallets:   []string{"wallet-a", "wallet-b", "wallet-c"},
+		Nodes:     []string{"relay-1", "relay-2"},
+		MaxAmount: big.NewInt(30),
+	}
+
+	g := NewGraph("relay-1", p2p)
+	preimage := "shared-secret"
+	hash := core.HashPreimage(preimage)
+	amount := big.NewInt(30)
+
+	locked, err := g.LockRoute(route, hash, amount, 10)
+	if err != nil {
+		t.Fatalf("LockRoute: %v", err)
+	}
+	if len(locked) != 2 {
+		t.Fatalf("expected 2 locked hops, got %d", len(locked))
+	}
+
+	a, err := p2p.GetLatestState("wallet-a")
+	if err != nil {
+		t.Fatalf("GetLatestState(wallet-a): %v", err)
+	}
+	if a.AvailableBalance().Cmp(big.NewInt(70)) != 0 {
+		t.Fatalf("wallet-a available balance = %s, want 70", a.AvailableBalance())
+	}
+	if got, want := locked[0].Pending[0].Expiry, uint64(10); got != want {
+		t.Fatalf("hop 0 expiry = %d, want %d", got, want)
+	}
+	if got, want := locked[1].Pending[0].Expiry, uint64(9); got != want {
+		t.Fatalf("hop 1 expiry = %d, want %d", got, want)
+	}
+
+	if err := g.SettleRoute(route, preimage); err != nil {
+		t.Fatalf("SettleRoute: %v", err)
+	}
+
+	a, err = p2p.GetLatestState("wallet-a")
+	if err != nil {
+		t.Fatalf("GetLatestState(wallet-a) after settle: %v", err)
+	}
+	if a.Balance.Cmp(big.NewInt(70)) != 0 {
+		t.Fatalf("wallet-a balance after settle = %s, want 70", a.Balance)
+	}
+	if len(a.Pending) != 0 {
+		t.Fatalf("wallet-a still has %d pending HTLCs after settle", len(a.Pending))
+	}
+
+	b, err := p2p.GetLatestState("wallet-b")
+	if err != nil {
+		t.Fatalf("GetLatestState(wallet-b) after settle: %v", err)
+	}
+	if b.Balance.Cmp(big.NewInt(70)) != 0 {
+		t.Fatalf("wallet-b balance after settle = %s, want 70", b.Balance)
+	}
+}
+
+func TestLockRoute_InsufficientLiquidityFailsCleanly(t *testing.T) {
+	p2p := mockp2p.NewMockP2P()
+	p2p.RegisterNode("relay-1", &signAllHandler{id: "relay-1"})
+
+	if err := p2p.PublishState(&core.State{
+		Wallet:       "wallet-a",
+		Token:        "token-usdt",
+		Version:      1,
+		Balance:      big.NewInt(5),
+		Participants: []string{"relay-1"},
+	}); err != nil {
+		t.Fatalf("seed wallet-a: %v", err)
+	}
+	if err := p2p.PublishState(&core.State{
+		Wallet:       "wallet-b",
+		Token:        "token-usdt",
+		Version:      1,
+		Balance:      big.NewInt(100),
+		Participants: []string{"relay-1"},
+	}); err != nil {
+		t.Fatalf("seed wallet-b: %v", err)
+	}
+
+	route := &Route{Wallets: []string{"wallet-a", "wallet-b"}, Nodes: []string{"relay-1"}, MaxAmount: big.NewInt(30)}
+	g := NewGraph("relay-1", p2p)
+
+	if _, err := g.LockRoute(route, core.HashPreimage("x"), big.NewInt(30), 10); err == nil {
+		t.Fatal("expected LockRoute to fail when wallet-a can't cover the amount")
+	}
+}
@@ -0,0 +1,246 @@
+package statestore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// listID identifies which of ARCCache's four lists a key currently lives in.
+type listID int
+
+const (
+	listT1 listID = iota
+	listT2
+	listB1
+	listB2
+)
+
+// ARCCache wraps a Blockstore with an Adaptive Replacement Cache: the
+// standard T1/T2/B1/B2 four-list algorithm (recency list T1, frequency list
+// T2, plus ghost lists B1/B2 tracking recently evicted keys). Capacity K is
+// split adaptively between T1 and T2 based on which ghost list keeps getting
+// hit, so the cache leans toward recency or frequency depending on the
+// observed access pattern.
+type ARCCache struct {
+	underlying Blockstore
+	capacity   int
+
+	mu sync.Mutex
+	p  int // target size for T1
+
+	t1, t2, b1, b2 *list.List
+	elems          map[string]*list.Element // key -> its element in whichever list holds it
+	loc            map[string]listID        // key -> which list holds it
+	values         map[string]*core.State   // cached payloads for T1/T2 members only
+}
+
+// NewARCCache wraps underlying with an ARC of the given capacity (K).
+func NewARCCache(underlying Blockstore, capacity int) *ARCCache {
+	return &ARCCache{
+		underlying: underlying,
+		capacity:   capacity,
+		t1:         list.New(),
+		t2:         list.New(),
+		b1:         list.New(),
+		b2:         list.New(),
+		elems:      make(map[string]*list.Element),
+		loc:        make(map[string]listID),
+		values:     make(map[string]*core.State),
+	}
+}
+
+func (c *ARCCache) Put(state *core.State) error {
+	if err := c.underlying.Put(state); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordAccess(state.Hash(), state)
+	return nil
+}
+
+func (c *ARCCache) Get(hash string) (*core.State, error) {
+	c.mu.Lock()
+	if v, cached := c.values[hash]; cached {
+		c.promote(hash)
+		c.mu.Unlock()
+		return v.Clone(), nil
+	}
+	c.mu.Unlock()
+
+	state, err := c.underlying.Get(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.recordAccess(hash, state)
+	c.mu.Unlock()
+	return state, nil
+}
+
+func (c *ARCCache) Has(hash string) (bool, error) {
+	c.mu.Lock()
+	_, cached := c.values[hash]
+	c.mu.Unlock()
+	if cached {
+		return true, nil
+	}
+	return c.underlying.Has(hash)
+}
+
+func (c *ARCCache) AllKeysChan(ctx context.Context) <-chan string {
+	return c.underlying.AllKeysChan(ctx)
+}
+
+// GetLatest delegates to the underlying store's version index, if it has one.
+func (c *ARCCache) GetLatest(wallet, token string) (*core.State, error) {
+	vi, ok := c.underlying.(VersionIndexed)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return vi.GetLatest(wallet, token)
+}
+
+// promote moves an already-cached key (T1 or T2) to the MRU end of T2: any
+// second access marks it "frequent" per the ARC scheme.
+func (c *ARCCache) promote(hash string) {
+	elem, ok := c.elems[hash]
+	if !ok {
+		return
+	}
+	switch c.loc[hash] {
+	case listT1:
+		c.t1.Remove(elem)
+	case listT2:
+		c.t2.Remove(elem)
+	default:
+		return
+	}
+	c.elems[hash] = c.t2.PushFront(hash)
+	c.loc[hash] = listT2
+}
+
+// recordAccess implements the ARC miss/ghost-hit path (see the Megiddo &
+// Modha ARC paper): ghost hits adapt p toward whichever list they came from,
+// then the key is (re)inserted into T2 or T1 as appropriate, evicting via
+// replace() when the cache is full.
+func (c *ARCCache) recordAccess(hash string, state *core.State) {
+	if loc, ok := c.loc[hash]; ok {
+		switch loc {
+		case listT1, listT2:
+			c.values[hash] = state
+			c.promote(hash)
+			return
+		case listB1:
+			b1Len, b2Len := c.b1.Len(), c.b2.Len()
+			delta := 1
+			if b1Len > 0 && b2Len > b1Len {
+				delta = b2Len / b1Len
+			}
+			c.p = min(c.capacity, c.p+delta)
+			c.replace(false)
+			c.moveToT2(hash, c.b1, state)
+			return
+		case listB2:
+			b1Len, b2Len := c.b1.Len(), c.b2.Len()
+			delta := 1
+			if b2Len > 0 && b1Len > b2Len {
+				delta = b1Len / b2Len
+			}
+			c.p = max(0, c.p-delta)
+			c.replace(true)
+			c.moveToT2(hash, c.b2, state)
+			return
+		}
+	}
+
+	// Brand new key.
+	l1Len := c.t1.Len() + c.b1.Len()
+	if l1Len == c.capacity {
+		if c.t1.Len() < c.capacity {
+			c.evictGhost(c.b1)
+			c.replace(false)
+		} else {
+			c.evictCacheEntry(c.t1)
+		}
+	} else if l1Len < c.capacity && (l1Len+c.t2.Len()+c.b2.Len()) >= c.capacity {
+		if l1Len+c.t2.Len()+c.b1.Len()+c.b2.Len() >= 2*c.capacity {
+			c.evictGhost(c.b2)
+		}
+		c.replace(false)
+	}
+	c.elems[hash] = c.t1.PushFront(hash)
+	c.loc[hash] = listT1
+	c.values[hash] = state
+}
+
+// moveToT2 relocates a ghost-listed key into T2 (a ghost hit is a full
+// cache hit for ARC's purposes: the state is refetched by the caller and
+// handed in here).
+func (c *ARCCache) moveToT2(hash string, ghost *list.List, state *core.State) {
+	ghost.Remove(c.elems[hash])
+	c.elems[hash] = c.t2.PushFront(hash)
+	c.loc[hash] = listT2
+	c.values[hash] = state
+}
+
+// replace evicts one entry from T1 or T2 into its ghost list, per the ARC
+// REPLACE procedure. inB2 indicates the access that triggered this came
+// from a B2 ghost hit (biases the choice toward evicting from T1).
+func (c *ARCCache) replace(inB2 bool) {
+	if c.t1.Len() > 0 && (c.t1.Len() > c.p || (inB2 && c.t1.Len() == c.p)) {
+		c.evictCacheEntry(c.t1)
+	} else if c.t2.Len() > 0 {
+		c.evictCacheEntryTo(c.t2, c.b2, listB2)
+	} else if c.t1.Len() > 0 {
+		c.evictCacheEntry(c.t1)
+	}
+}
+
+// evictCacheEntry moves the LRU entry of T1 into its ghost list B1.
+func (c *ARCCache) evictCacheEntry(l *list.List) {
+	c.evictCacheEntryTo(l, c.b1, listB1)
+}
+
+func (c *ARCCache) evictCacheEntryTo(l *list.List, ghost *list.List, ghostID listID) {
+	back := l.Back()
+	if back == nil {
+		return
+	}
+	hash := back.Value.(string)
+	l.Remove(back)
+	delete(c.values, hash)
+	c.elems[hash] = ghost.PushFront(hash)
+	c.loc[hash] = ghostID
+}
+
+// evictGhost drops the LRU entry of a ghost list entirely (it's aged out).
+func (c *ARCCache) evictGhost(l *list.List) {
+	back := l.Back()
+	if back == nil {
+		return
+	}
+	hash := back.Value.(string)
+	l.Remove(back)
+	delete(c.elems, hash)
+	delete(c.loc, hash)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,140 @@
+package statestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// bloomFilter is a small self-contained Bloom filter using double hashing
+// (Kirsch-Mitzenmacher) to derive k index positions from a single SHA-256
+// digest, avoiding the need for k independent hash functions.
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+}
+
+func newBloomFilter(expectedItems uint64, falsePositiveRate float64) *bloomFilter {
+	if expectedItems == 0 {
+		expectedItems = 1
+	}
+	m := optimalBits(expectedItems, falsePositiveRate)
+	k := optimalHashes(m, expectedItems)
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func optimalBits(n uint64, p float64) uint64 {
+	m := -1 * float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)
+	if m < 1 {
+		m = 1
+	}
+	return uint64(math.Ceil(m))
+}
+
+func optimalHashes(m, n uint64) uint64 {
+	k := math.Round(float64(m) / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return uint64(k)
+}
+
+func (f *bloomFilter) positions(key string) (h1, h2 uint64) {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[0:8]), binary.BigEndian.Uint64(sum[8:16])
+}
+
+func (f *bloomFilter) Add(key string) {
+	h1, h2 := f.positions(key)
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+// Test reports whether key might be present (false positives possible;
+// false negatives are not).
+func (f *bloomFilter) Test(key string) bool {
+	h1, h2 := f.positions(key)
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// BloomCache wraps a Blockstore with a Bloom filter over known hashes so
+// negative Has/Get lookups short-circuit before touching the underlying
+// store (e.g. DuckDB, which is otherwise the most expensive place to learn
+// "no").
+type BloomCache struct {
+	underlying Blockstore
+
+	mu     sync.RWMutex
+	filter *bloomFilter
+}
+
+// NewBloomCache wraps underlying with a filter sized for expectedItems at
+// falsePositiveRate.
+func NewBloomCache(underlying Blockstore, expectedItems uint64, falsePositiveRate float64) *BloomCache {
+	return &BloomCache{
+		underlying: underlying,
+		filter:     newBloomFilter(expectedItems, falsePositiveRate),
+	}
+}
+
+func (b *BloomCache) Put(state *core.State) error {
+	if err := b.underlying.Put(state); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.filter.Add(state.Hash())
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *BloomCache) Get(hash string) (*core.State, error) {
+	if !b.mightHave(hash) {
+		return nil, ErrNotFound
+	}
+	return b.underlying.Get(hash)
+}
+
+func (b *BloomCache) Has(hash string) (bool, error) {
+	if !b.mightHave(hash) {
+		return false, nil
+	}
+	return b.underlying.Has(hash)
+}
+
+func (b *BloomCache) mightHave(hash string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.filter.Test(hash)
+}
+
+func (b *BloomCache) AllKeysChan(ctx context.Context) <-chan string {
+	return b.underlying.AllKeysChan(ctx)
+}
+
+// GetLatest delegates to the underlying store's version index, if it has
+// one. The Bloom filter only guards content-hash lookups, not the
+// wallet+token index.
+func (b *BloomCache) GetLatest(wallet, token string) (*core.State, error) {
+	vi, ok := b.underlying.(VersionIndexed)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return vi.GetLatest(wallet, token)
+}
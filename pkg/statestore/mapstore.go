@@ -0,0 +1,98 @@
+package statestore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// latestEntry is the secondary-index record tracking a wallet+token's
+// highest known version.
+type latestEntry struct {
+	Hash    string
+	Version uint64
+}
+
+// MapStore is a plain in-memory Blockstore, the ground-truth backing store
+// that ARCCache/BloomCache decorate.
+type MapStore struct {
+	mu     sync.RWMutex
+	blocks map[string]*core.State
+	latest map[string]latestEntry // indexKey(wallet, token) -> highest version seen
+}
+
+// NewMapStore creates an empty MapStore.
+func NewMapStore() *MapStore {
+	return &MapStore{
+		blocks: make(map[string]*core.State),
+		latest: make(map[string]latestEntry),
+	}
+}
+
+func (m *MapStore) Put(state *core.State) error {
+	hash := state.Hash()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blocks[hash] = state.Clone()
+
+	key := indexKey(state.Wallet, state.Token)
+	if cur, exists := m.latest[key]; !exists || state.Version > cur.Version {
+		m.latest[key] = latestEntry{Hash: hash, Version: state.Version}
+	}
+	return nil
+}
+
+func (m *MapStore) Get(hash string) (*core.State, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, ok := m.blocks[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return state.Clone(), nil
+}
+
+func (m *MapStore) Has(hash string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.blocks[hash]
+	return ok, nil
+}
+
+func (m *MapStore) AllKeysChan(ctx context.Context) <-chan string {
+	m.mu.RLock()
+	keys := make([]string, 0, len(m.blocks))
+	for k := range m.blocks {
+		keys = append(keys, k)
+	}
+	m.mu.RUnlock()
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for _, k := range keys {
+			select {
+			case out <- k:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// GetLatest returns the highest-version state stored for wallet+token,
+// looked up via the secondary index rather than a scan.
+func (m *MapStore) GetLatest(wallet, token string) (*core.State, error) {
+	m.mu.RLock()
+	entry, ok := m.latest[indexKey(wallet, token)]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return m.Get(entry.Hash)
+}
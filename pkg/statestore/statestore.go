@@ -0,0 +1,35 @@
+// Package statestore treats core.State as a content-addressed block, keyed
+// by State.Hash(), following the IPFS blockstore/CID pattern. A plain
+// MapStore is the ground truth; ARCCache and BloomCache wrap any Blockstore
+// to keep hot lookups off the underlying store.
+package statestore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// ErrNotFound is returned by Get when no block exists for a hash.
+var ErrNotFound = errors.New("statestore: block not found")
+
+// Blockstore stores core.State blocks keyed by their content hash
+// (State.Hash()).
+type Blockstore interface {
+	Put(state *core.State) error
+	Get(hash string) (*core.State, error)
+	Has(hash string) (bool, error)
+	AllKeysChan(ctx context.Context) <-chan string
+}
+
+// VersionIndexed is implemented by blockstores that maintain a secondary
+// index from wallet+token to their highest-version block, so GetLatest can
+// answer without scanning every key.
+type VersionIndexed interface {
+	GetLatest(wallet, token string) (*core.State, error)
+}
+
+func indexKey(wallet, token string) string {
+	return wallet + ":" + token
+}
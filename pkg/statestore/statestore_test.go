@@ -0,0 +1,187 @@
+package statestore
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+func mkState(wallet string, version uint64) *core.State {
+	return &core.State{Wallet: wallet, Token: "usdt", Version: version, Balance: big.NewInt(int64(version))}
+}
+
+func TestMapStore_PutGetHasAndLatest(t *testing.T) {
+	m := NewMapStore()
+	s1 := mkState("wallet-a", 1)
+	s2 := mkState("wallet-a", 2)
+
+	if err := m.Put(s1); err != nil {
+		t.Fatalf("Put(s1): %v", err)
+	}
+	if err := m.Put(s2); err != nil {
+		t.Fatalf("Put(s2): %v", err)
+	}
+
+	if has, err := m.Has(s1.Hash()); err != nil || !has {
+		t.Fatalf("Has(s1) = %v, %v, want true, nil", has, err)
+	}
+	if _, err := m.Get("does-not-exist"); err != ErrNotFound {
+		t.Fatalf("Get(missing) = %v, want ErrNotFound", err)
+	}
+
+	latest, err := m.GetLatest("wallet-a", "usdt")
+	if err != nil {
+		t.Fatalf("GetLatest: %v", err)
+	}
+	if latest.Version != 2 {
+		t.Fatalf("GetLatest version = %d, want 2", latest.Version)
+	}
+}
+
+func TestMapStore_AllKeysChanYieldsEveryPutBlock(t *testing.T) {
+	m := NewMapStore()
+	hashes := map[string]bool{}
+	for i := uint64(1); i <= 3; i++ {
+		s := mkState("wallet-a", i)
+		hashes[s.Hash()] = true
+		if err := m.Put(s); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	seen := map[string]bool{}
+	for k := range m.AllKeysChan(context.Background()) {
+		seen[k] = true
+	}
+	if len(seen) != len(hashes) {
+		t.Fatalf("AllKeysChan yielded %d keys, want %d", len(seen), len(hashes))
+	}
+	for h := range hashes {
+		if !seen[h] {
+			t.Fatalf("AllKeysChan missed hash %s", h)
+		}
+	}
+}
+
+func TestARCCache_EvictedEntryStillServesFromUnderlying(t *testing.T) {
+	underlying := NewMapStore()
+	cache := NewARCCache(underlying, 2)
+
+	states := []*core.State{mkState("wallet-a", 1), mkState("wallet-b", 1), mkState("wallet-c", 1)}
+	for _, s := range states {
+		if err := cache.Put(s); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	// Capacity is 2, so the first key put (wallet-a) should have been
+	// evicted from T1 into the ghost list B1 by the time the third lands.
+	got, err := cache.Get(states[0].Hash())
+	if err != nil {
+		t.Fatalf("Get(evicted key) should still succeed via underlying: %v", err)
+	}
+	if got.Wallet != "wallet-a" {
+		t.Fatalf("Get(evicted key) returned wrong state: %+v", got)
+	}
+}
+
+func TestARCCache_RepeatedAccessPromotesToT2(t *testing.T) {
+	underlying := NewMapStore()
+	cache := NewARCCache(underlying, 4)
+
+	s := mkState("wallet-a", 1)
+	if err := cache.Put(s); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := cache.Get(s.Hash()); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	cache.mu.Lock()
+	loc := cache.loc[s.Hash()]
+	cache.mu.Unlock()
+	if loc != listT2 {
+		t.Fatalf("after a repeat access, key should have been promoted to T2, got list %v", loc)
+	}
+}
+
+func TestARCCache_GetLatestDelegatesToUnderlying(t *testing.T) {
+	underlying := NewMapStore()
+	cache := NewARCCache(underlying, 4)
+
+	if err := cache.Put(mkState("wallet-a", 1)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := cache.Put(mkState("wallet-a", 5)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	latest, err := cache.GetLatest("wallet-a", "usdt")
+	if err != nil {
+		t.Fatalf("GetLatest: %v", err)
+	}
+	if latest.Version != 5 {
+		t.Fatalf("GetLatest version = %d, want 5", latest.Version)
+	}
+}
+
+// countingStore wraps a Blockstore and counts calls that reach it, so tests
+// can confirm BloomCache actually short-circuits negative lookups.
+type countingStore struct {
+	Blockstore
+	gets, hases int
+}
+
+func (c *countingStore) Get(hash string) (*core.State, error) {
+	c.gets++
+	return c.Blockstore.Get(hash)
+}
+
+func (c *countingStore) Has(hash string) (bool, error) {
+	c.hases++
+	return c.Blockstore.Has(hash)
+}
+
+func TestBloomCache_NegativeLookupNeverTouchesUnderlying(t *testing.T) {
+	underlying := &countingStore{Blockstore: NewMapStore()}
+	cache := NewBloomCache(underlying, 100, 0.0001)
+
+	// With a false-positive rate this low and nothing ever added, this
+	// key's absence should be caught by the filter alone.
+	if has, err := cache.Has("never-added"); err != nil || has {
+		t.Fatalf("Has(never-added) = %v, %v, want false, nil", has, err)
+	}
+	if underlying.hases != 0 {
+		t.Fatalf("expected the underlying store's Has to never be called, got %d calls", underlying.hases)
+	}
+
+	if _, err := cache.Get("never-added"); err != ErrNotFound {
+		t.Fatalf("Get(never-added) = %v, want ErrNotFound", err)
+	}
+	if underlying.gets != 0 {
+		t.Fatalf("expected the underlying store's Get to never be called, got %d calls", underlying.gets)
+	}
+}
+
+func TestBloomCache_PositiveLookupReachesUnderlying(t *testing.T) {
+	underlying := NewMapStore()
+	cache := NewBloomCache(underlying, 100, 0.0001)
+
+	s := mkState("wallet-a", 1)
+	if err := cache.Put(s); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if has, err := cache.Has(s.Hash()); err != nil || !has {
+		t.Fatalf("Has(s) = %v, %v, want true, nil", has, err)
+	}
+	got, err := cache.Get(s.Hash())
+	if err != nil {
+		t.Fatalf("Get(s): %v", err)
+	}
+	if got.Wallet != "wallet-a" {
+		t.Fatalf("Get(s) returned wrong state: %+v", got)
+	}
+}
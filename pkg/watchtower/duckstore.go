@@ -0,0 +1,198 @@
+package watchtower
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// schema mirrors the transactions/signatures tables in cmd/dstore's
+// schema.sql: wide columns stored as TEXT rather than native DECIMAL/LIST
+// types, since the values (big.Int amounts, signature bytes) only ever need
+// to round-trip through Go, not be queried as numbers inside DuckDB.
+const schema = `
+CREATE TABLE IF NOT EXISTS bundles (
+	wallet       TEXT PRIMARY KEY,
+	token        TEXT,
+	version      BIGINT,
+	balance      TEXT,
+	participants TEXT,
+	sigs         TEXT,
+	fee          TEXT
+);
+
+CREATE TABLE IF NOT EXISTS bounties (
+	wallet    TEXT,
+	amount    TEXT,
+	earned_at TIMESTAMP DEFAULT current_timestamp
+);
+`
+
+// DuckStore is the persistent Store, backed by an embedded DuckDB database
+// (see cmd/dstore for the same database/sql + duckdb-go/v2 usage this
+// mirrors).
+type DuckStore struct {
+	db *sql.DB
+}
+
+// NewDuckStore opens (or creates) the DuckDB database at path and applies
+// schema. path may be "" for an in-memory database.
+func NewDuckStore(path string) (*DuckStore, error) {
+	db, err := sql.Open("duckdb", path)
+	if err != nil {
+		return nil, fmt.Errorf("watchtower: open duckdb: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("watchtower: apply schema: %w", err)
+	}
+	return &DuckStore{db: db}, nil
+}
+
+func (s *DuckStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *DuckStore) PutBundle(b *Bundle) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO bundles (wallet, token, version, balance, participants, sigs, fee)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, b.Wallet, b.State.Token, b.State.Version, b.State.Balance.String(),
+		encodeParticipants(b.State.Participants), encodeSigs(b.State.Sigs), b.Fee.String())
+	if err != nil {
+		return fmt.Errorf("watchtower: insert bundle: %w", err)
+	}
+	return nil
+}
+
+func (s *DuckStore) GetBundle(wallet string) (*Bundle, error) {
+	row := s.db.QueryRow(`
+		SELECT wallet, token, version, balance, participants, sigs, fee
+		FROM bundles WHERE wallet = ?
+	`, wallet)
+	b, err := scanBundle(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoBundle
+	}
+	if err != nil {
+		return nil, fmt.Errorf("watchtower: query bundle: %w", err)
+	}
+	return b, nil
+}
+
+func (s *DuckStore) DeleteBundle(wallet string) error {
+	_, err := s.db.Exec(`DELETE FROM bundles WHERE wallet = ?`, wallet)
+	if err != nil {
+		return fmt.Errorf("watchtower: delete bundle: %w", err)
+	}
+	return nil
+}
+
+func (s *DuckStore) AllBundles() ([]*Bundle, error) {
+	rows, err := s.db.Query(`SELECT wallet, token, version, balance, participants, sigs, fee FROM bundles`)
+	if err != nil {
+		return nil, fmt.Errorf("watchtower: query bundles: %w", err)
+	}
+	defer rows.Close()
+
+	var bundles []*Bundle
+	for rows.Next() {
+		b, err := scanBundle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("watchtower: scan bundle: %w", err)
+		}
+		bundles = append(bundles, b)
+	}
+	return bundles, rows.Err()
+}
+
+func (s *DuckStore) RecordBounty(wallet string, amount *big.Int) error {
+	_, err := s.db.Exec(`INSERT INTO bounties (wallet, amount) VALUES (?, ?)`, wallet, amount.String())
+	if err != nil {
+		return fmt.Errorf("watchtower: record bounty: %w", err)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBundle(row rowScanner) (*Bundle, error) {
+	var (
+		wallet, token, balanceStr, participantsStr, sigsStr, feeStr string
+		version                                                     uint64
+	)
+	if err := row.Scan(&wallet, &token, &version, &balanceStr, &participantsStr, &sigsStr, &feeStr); err != nil {
+		return nil, err
+	}
+
+	balance, ok := new(big.Int).SetString(balanceStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("watchtower: corrupt balance %q for %s", balanceStr, wallet)
+	}
+	fee, ok := new(big.Int).SetString(feeStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("watchtower: corrupt fee %q for %s", feeStr, wallet)
+	}
+	sigs, err := decodeSigs(sigsStr)
+	if err != nil {
+		return nil, fmt.Errorf("watchtower: corrupt sigs for %s: %w", wallet, err)
+	}
+
+	return &Bundle{
+		Wallet: wallet,
+		State: &core.State{
+			Wallet:       wallet,
+			Token:        token,
+			Version:      version,
+			Balance:      balance,
+			Participants: decodeParticipants(participantsStr),
+			Sigs:         sigs,
+		},
+		Fee: fee,
+	}, nil
+}
+
+func encodeParticipants(participants []string) string {
+	return strings.Join(participants, ",")
+}
+
+func decodeParticipants(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func encodeSigs(sigs [][]byte) string {
+	hexSigs := make([]string, len(sigs))
+	for i, sig := range sigs {
+		hexSigs[i] = hex.EncodeToString(sig)
+	}
+	return strings.Join(hexSigs, ",")
+}
+
+func decodeSigs(s string) ([][]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	sigs := make([][]byte, len(parts))
+	for i, p := range parts {
+		sig, err := hex.DecodeString(p)
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = sig
+	}
+	return sigs, nil
+}
@@ -0,0 +1,184 @@
+// Package watchtower implements a breach arbiter for the off-chain state
+// channel, modeled on the Lightning Network's watchtowers: a client who
+// expects to go offline during a withdrawal's challenge period pre-registers
+// a signed "justice bundle" (its latest core.State plus quorum signatures)
+// with one or more watchtowers. node.Node only challenges fraud it happens
+// to have in its own local store, so an offline client whose peers have all
+// churned would otherwise have no one watching on its behalf; a Watchtower
+// fills that gap by subscribing to the chain directly.
+package watchtower
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/mod/clearnet/pkg/core"
+	"github.com/mod/clearnet/pkg/ports"
+)
+
+// ErrNoBundle is returned by RevokeBundle (and used internally) when no
+// bundle is registered for a wallet.
+var ErrNoBundle = errors.New("watchtower: no bundle registered for wallet")
+
+// Bundle is a client's pre-registered justice package for a single wallet:
+// the latest state it has collected quorum signatures for, and the bounty it
+// is willing to pay a watchtower that has to use it.
+type Bundle struct {
+	Wallet string
+	State  *core.State // must already carry Participants/Sigs
+	Fee    *big.Int    // bounty owed to the watchtower if it challenges on Wallet's behalf
+}
+
+// Store persists registered bundles and the bounties a watchtower has earned
+// by using them, so a Watchtower process can restart without losing its book
+// of clients. See DuckStore for the persistent implementation.
+type Store interface {
+	PutBundle(b *Bundle) error
+	GetBundle(wallet string) (*Bundle, error)
+	DeleteBundle(wallet string) error
+	AllBundles() ([]*Bundle, error)
+
+	// RecordBounty logs amount (in the same units as core.State.Balance) in
+	// the watchtower's own earnings ledger for wallet, after
+	// chain.Challenge has already carved that amount out of wallet's
+	// on-chain balance and credited it to this watchtower: a local record
+	// of a payout that already happened on-chain, not an IOU.
+	RecordBounty(wallet string, amount *big.Int) error
+}
+
+// Watchtower watches a ports.BlockchainAdapter's event stream for
+// withdrawal requests that are stale relative to a registered Bundle, and
+// challenges them automatically.
+type Watchtower struct {
+	id    string // challengerID passed to chain.Challenge, and this tower's identity in bounty bookkeeping
+	chain ports.BlockchainAdapter
+	store Store
+
+	mu      sync.RWMutex
+	bundles map[string]*Bundle // Wallet -> latest registered bundle
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// New loads every bundle already in store and starts watching chain for
+// withdrawal requests against them.
+func New(id string, chain ports.BlockchainAdapter, store Store) (*Watchtower, error) {
+	existing, err := store.AllBundles()
+	if err != nil {
+		return nil, fmt.Errorf("watchtower: load bundles: %w", err)
+	}
+
+	w := &Watchtower{
+		id:      id,
+		chain:   chain,
+		store:   store,
+		bundles: make(map[string]*Bundle, len(existing)),
+		stopCh:  make(chan struct{}),
+	}
+	for _, b := range existing {
+		w.bundles[b.Wallet] = b
+	}
+
+	go w.watchChain(chain.Subscribe())
+	return w, nil
+}
+
+// Close stops the chain-watching goroutine. It does not close store.
+func (w *Watchtower) Close() {
+	w.stopOnce.Do(func() { close(w.stopCh) })
+}
+
+// RegisterBundle persists b and starts watching withdrawal requests for
+// b.Wallet. A later call for the same wallet replaces the previous bundle.
+func (w *Watchtower) RegisterBundle(b *Bundle) error {
+	if b.Wallet == "" {
+		return errors.New("watchtower: bundle has no wallet")
+	}
+	if b.State == nil {
+		return errors.New("watchtower: bundle has no state")
+	}
+	if err := w.store.PutBundle(b); err != nil {
+		return fmt.Errorf("watchtower: register bundle for %s: %w", b.Wallet, err)
+	}
+
+	w.mu.Lock()
+	w.bundles[b.Wallet] = b
+	w.mu.Unlock()
+	return nil
+}
+
+// RevokeBundle stops watching wallet and deletes its bundle.
+func (w *Watchtower) RevokeBundle(wallet string) error {
+	w.mu.RLock()
+	_, exists := w.bundles[wallet]
+	w.mu.RUnlock()
+	if !exists {
+		return ErrNoBundle
+	}
+
+	if err := w.store.DeleteBundle(wallet); err != nil {
+		return fmt.Errorf("watchtower: revoke bundle for %s: %w", wallet, err)
+	}
+
+	w.mu.Lock()
+	delete(w.bundles, wallet)
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *Watchtower) watchChain(events <-chan ports.BlockchainEvent) {
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.Type == ports.EventWithdrawalRequested {
+				w.handleWithdrawalRequested(ev)
+			}
+		}
+	}
+}
+
+// handleWithdrawalRequested challenges pending on-chain if it's older than
+// the bundle registered for its wallet, the same staleness check
+// node.Node.handleWithdrawalRequest makes against its own local store.
+func (w *Watchtower) handleWithdrawalRequested(ev ports.BlockchainEvent) {
+	p, ok := ev.Payload.(ports.RequestPayload)
+	if !ok || p.State == nil {
+		return
+	}
+	pending := p.State
+
+	w.mu.RLock()
+	bundle, exists := w.bundles[pending.Wallet]
+	w.mu.RUnlock()
+	if !exists || bundle.State.Version <= pending.Version {
+		return
+	}
+
+	fmt.Printf("[Watchtower %s] %s requested withdrawal at stale v%d (have v%d); challenging\n",
+		w.id, pending.Wallet, pending.Version, bundle.State.Version)
+
+	bounty := bundle.Fee
+	if bounty == nil {
+		bounty = big.NewInt(0)
+	}
+	// Challenge itself carves bounty out of pending.Wallet's on-chain
+	// balance and credits it to w.id, capped at whatever is actually there
+	// to slash; RecordBounty below just keeps this tower's own ledger of
+	// what it's actually been paid in sync with that.
+	if err := w.chain.Challenge(bundle.State, w.id, bounty); err != nil {
+		fmt.Printf("[Watchtower %s] challenge failed for %s: %v\n", w.id, pending.Wallet, err)
+		return
+	}
+
+	if err := w.store.RecordBounty(pending.Wallet, bounty); err != nil {
+		fmt.Printf("[Watchtower %s] record bounty for %s: %v\n", w.id, pending.Wallet, err)
+	}
+}
@@ -0,0 +1,175 @@
+package watchtower
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mod/clearnet/pkg/adapters/mockchain"
+	"github.com/mod/clearnet/pkg/core"
+)
+
+// memStore is a minimal in-memory Store, standing in for DuckStore in tests.
+type memStore struct {
+	mu       sync.Mutex
+	bundles  map[string]*Bundle
+	bounties map[string]*big.Int
+}
+
+func newMemStore() *memStore {
+	return &memStore{bundles: make(map[string]*Bundle), bounties: make(map[string]*big.Int)}
+}
+
+func (s *memStore) PutBundle(b *Bundle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bundles[b.Wallet] = b
+	return nil
+}
+
+func (s *memStore) GetBundle(wallet string) (*Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bundles[wallet]
+	if !ok {
+		return nil, ErrNoBundle
+	}
+	return b, nil
+}
+
+func (s *memStore) DeleteBundle(wallet string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.bundles, wallet)
+	return nil
+}
+
+func (s *memStore) AllBundles() ([]*Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Bundle, 0, len(s.bundles))
+	for _, b := range s.bundles {
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func (s *memStore) RecordBounty(wallet string, amount *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total, ok := s.bounties[wallet]
+	if !ok {
+		total = big.NewInt(0)
+	}
+	s.bounties[wallet] = new(big.Int).Add(total, amount)
+	return nil
+}
+
+func (s *memStore) bountyFor(wallet string) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.bounties[wallet]; ok {
+		return b
+	}
+	return big.NewInt(0)
+}
+
+func TestWatchtower_ChallengesStaleWithdrawalAndPaysBounty(t *testing.T) {
+	chain := mockchain.NewVaultContract(50 * time.Millisecond)
+	chain.AddNode("node-0")
+
+	if err := chain.Deposit("wallet-a", "usdt", big.NewInt(100)); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	store := newMemStore()
+	tower, err := New("tower-1", chain, store)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer tower.Close()
+
+	latest := &core.State{Wallet: "wallet-a", Token: "usdt", Version: 5, Balance: big.NewInt(40), Participants: []string{"node-0"}}
+	if err := tower.RegisterBundle(&Bundle{Wallet: "wallet-a", State: latest, Fee: big.NewInt(10)}); err != nil {
+		t.Fatalf("RegisterBundle: %v", err)
+	}
+
+	stale := &core.State{Wallet: "wallet-a", Token: "usdt", Version: 3, Balance: big.NewInt(70), Participants: []string{"node-0"}}
+	if err := chain.RequestWithdrawal(stale); err != nil {
+		t.Fatalf("RequestWithdrawal: %v", err)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		if chain.Balance("tower-1").Sign() > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for the watchtower to challenge the stale withdrawal")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if got, want := chain.Balance("tower-1"), big.NewInt(10); got.Cmp(want) != 0 {
+		t.Fatalf("tower-1 on-chain balance = %s, want %s", got, want)
+	}
+	if got, want := chain.Balance("wallet-a"), big.NewInt(90); got.Cmp(want) != 0 {
+		t.Fatalf("wallet-a on-chain balance after bounty = %s, want %s", got, want)
+	}
+	if got, want := store.bountyFor("wallet-a"), big.NewInt(10); got.Cmp(want) != 0 {
+		t.Fatalf("recorded bounty = %s, want %s", got, want)
+	}
+
+	// The withdrawal should actually have been defeated: withdrawing now
+	// should fail since the request was deleted, not merely outlived.
+	if err := chain.Withdraw("wallet-a"); err == nil {
+		t.Fatal("expected Withdraw to fail: the stale request should have been cancelled by the challenge")
+	}
+}
+
+func TestWatchtower_BountyCappedAtAvailableBalance(t *testing.T) {
+	chain := mockchain.NewVaultContract(50 * time.Millisecond)
+	chain.AddNode("node-0")
+
+	if err := chain.Deposit("wallet-a", "usdt", big.NewInt(5)); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	store := newMemStore()
+	tower, err := New("tower-1", chain, store)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer tower.Close()
+
+	latest := &core.State{Wallet: "wallet-a", Token: "usdt", Version: 5, Balance: big.NewInt(40), Participants: []string{"node-0"}}
+	if err := tower.RegisterBundle(&Bundle{Wallet: "wallet-a", State: latest, Fee: big.NewInt(10)}); err != nil {
+		t.Fatalf("RegisterBundle: %v", err)
+	}
+
+	stale := &core.State{Wallet: "wallet-a", Token: "usdt", Version: 3, Balance: big.NewInt(70), Participants: []string{"node-0"}}
+	if err := chain.RequestWithdrawal(stale); err != nil {
+		t.Fatalf("RequestWithdrawal: %v", err)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		if chain.Balance("tower-1").Sign() > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for the watchtower to challenge the stale withdrawal")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if got, want := chain.Balance("tower-1"), big.NewInt(5); got.Cmp(want) != 0 {
+		t.Fatalf("bounty should be capped at wallet-a's available balance (5), got %s", got)
+	}
+	if got := chain.Balance("wallet-a"); got.Sign() != 0 {
+		t.Fatalf("wallet-a's balance should be fully slashed to 0, got %s", got)
+	}
+}